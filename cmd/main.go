@@ -2,14 +2,26 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
 	"github.com/faeelol/companies-store/internal/app"
 	"github.com/faeelol/companies-store/internal/app/database"
+	"github.com/faeelol/companies-store/internal/app/logic/republish"
+	"github.com/faeelol/companies-store/internal/app/model"
+	"github.com/faeelol/companies-store/internal/app/rest"
 )
 
 const (
@@ -25,43 +37,172 @@ func NewRootCommand() *cobra.Command {
 		RunE: func(_ *cobra.Command, _ []string) error {
 			return errors.New("choose setup mode")
 		},
+		// main logs the error, the usage would bury it
+		SilenceErrors: true,
+		SilenceUsage:  true,
 	}
 	rootCmd.PersistentFlags().StringVar(&configPath, "config", "configs/config.example.yml", "path to config file")
 
 	rootCmd.AddCommand(NewHTTPServerCommand())
+	rootCmd.AddCommand(NewGRPCServerCommand())
 	rootCmd.AddCommand(NewMigrateDBCommand())
+	rootCmd.AddCommand(NewPurgeCommand())
+	rootCmd.AddCommand(NewOpenAPICommand())
+	rootCmd.AddCommand(NewOutboxRelayCommand())
+	rootCmd.AddCommand(NewWebhookWorkerCommand())
+	rootCmd.AddCommand(NewConsumeCommand())
+	rootCmd.AddCommand(NewRepublishCommand())
+	rootCmd.AddCommand(NewConfigCommand())
 
 	rootCmd.Version = version
 	return rootCmd
 }
 
 func NewHTTPServerCommand() *cobra.Command {
+	var withGRPC bool
 	cmd := &cobra.Command{
 		Use:   "http",
 		Short: "HTTP server",
-		RunE: func(_ *cobra.Command, _ []string) error {
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cfg := app.NewConfig()
+			return app.LoadConfigInitLoggerAndDo(configPath, cfg, func(logger *logrus.Logger) error {
+				return app.StartHTTPService(cmd.Context(), cfg, logger, withGRPC)
+			})
+		},
+	}
+	cmd.Flags().BoolVar(&withGRPC, "with-grpc", false, "serve the gRPC API alongside")
+	return cmd
+}
+
+func NewGRPCServerCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "grpc",
+		Short: "gRPC server",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cfg := app.NewConfig()
+			return app.LoadConfigInitLoggerAndDo(configPath, cfg, func(logger *logrus.Logger) error {
+				return app.StartGRPCService(cmd.Context(), cfg, logger)
+			})
+		},
+	}
+	return cmd
+}
+
+func NewOutboxRelayCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "outbox-relay",
+		Short: "Publish the outbox events to the event sinks",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cfg := app.NewConfig()
+			return app.LoadConfigInitLoggerAndDo(configPath, cfg, func(logger *logrus.Logger) error {
+				return app.RunOutboxRelay(cmd.Context(), cfg, logger)
+			})
+		},
+	}
+	return cmd
+}
+
+func NewWebhookWorkerCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "webhook-worker",
+		Short: "Deliver the events to the webhook subscriptions",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cfg := app.NewConfig()
+			return app.LoadConfigInitLoggerAndDo(configPath, cfg, func(logger *logrus.Logger) error {
+				return app.RunWebhookWorker(cmd.Context(), cfg, logger)
+			})
+		},
+	}
+	return cmd
+}
+
+func NewConsumeCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "consume",
+		Short: "Apply the company commands read from Kafka",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cfg := app.NewConfig()
+			return app.LoadConfigInitLoggerAndDo(configPath, cfg, func(logger *logrus.Logger) error {
+				return app.RunCommandConsumer(cmd.Context(), cfg, logger)
+			})
+		},
+	}
+	return cmd
+}
+
+func NewRepublishCommand() *cobra.Command {
+	var (
+		topic          string
+		companyType    string
+		updatedFrom    string
+		updatedTo      string
+		includeDeleted bool
+		opts           republish.Options
+	)
+	cmd := &cobra.Command{
+		Use:   "republish",
+		Short: "Publish a snapshot event of every company",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			opts.Filter.IncludeDeleted = includeDeleted
+			if companyType != "" {
+				t := model.CompanyType(companyType)
+				if !t.IsValid() {
+					return fmt.Errorf("invalid --type value %q", companyType)
+				}
+				opts.Filter.Type = &t
+			}
+			var err error
+			if opts.Filter.UpdatedFrom, err = parseTimeFlag("updated-from", updatedFrom); err != nil {
+				return err
+			}
+			if opts.Filter.UpdatedTo, err = parseTimeFlag("updated-to", updatedTo); err != nil {
+				return err
+			}
+			if opts.Rate < 0 {
+				return errors.New("--rate must not be negative")
+			}
+
 			cfg := app.NewConfig()
 			return app.LoadConfigInitLoggerAndDo(configPath, cfg, func(logger *logrus.Logger) error {
-				return app.StartHTTPService(context.Background(), cfg, logger)
+				return app.RepublishCompanies(cmd.Context(), cfg, logger, topic, opts)
 			})
 		},
 	}
+	cmd.Flags().StringVar(&topic, "topic", "", "topic to publish to, the events topic by default")
+	cmd.Flags().StringVar(&companyType, "type", "", "only companies of this type")
+	cmd.Flags().StringVar(&updatedFrom, "updated-from", "", "only companies updated at or after this RFC 3339 time")
+	cmd.Flags().StringVar(&updatedTo, "updated-to", "", "only companies updated before this RFC 3339 time")
+	cmd.Flags().BoolVar(&includeDeleted, "include-deleted", false, "also publish soft-deleted companies")
+	cmd.Flags().IntVar(&opts.Rate, "rate", 0, "maximum number of events per second, 0 for no limit")
+	cmd.Flags().IntVar(&opts.BatchSize, "batch-size", republish.DefaultBatchSize, "number of events per batch")
+	cmd.Flags().StringVar(&opts.CheckpointPath, "checkpoint", "", "file saving the progress, a run resumes from it")
 	return cmd
 }
 
+func parseTimeFlag(name, value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --%s value %q: %w", name, value, err)
+	}
+	return &t, nil
+}
+
 func NewMigrateDBCommand() *cobra.Command {
 	var migrateDBDown bool
 	migrateDBCmd := &cobra.Command{
 		Use:   "migrate-db",
 		Short: "Migrate database",
-		RunE: func(_ *cobra.Command, _ []string) error {
+		RunE: func(cmd *cobra.Command, _ []string) error {
 			direction := database.MigrDirectionUp
 			if migrateDBDown {
 				direction = database.MigrDirectionDown
 			}
 			cfg := app.NewConfig()
 			return app.LoadConfigInitLoggerAndDo(configPath, cfg, func(logger *logrus.Logger) error {
-				return app.MigrateDatabase(context.Background(), cfg, direction, logger)
+				return app.MigrateDatabase(cmd.Context(), cfg, direction, logger)
 			})
 		},
 	}
@@ -69,8 +210,116 @@ func NewMigrateDBCommand() *cobra.Command {
 	return migrateDBCmd
 }
 
+func NewPurgeCommand() *cobra.Command {
+	var olderThan string
+	purgeCmd := &cobra.Command{
+		Use:   "purge",
+		Short: "Permanently remove soft-deleted companies",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			retention, err := parseRetention(olderThan)
+			if err != nil {
+				return err
+			}
+			cfg := app.NewConfig()
+			return app.LoadConfigInitLoggerAndDo(configPath, cfg, func(logger *logrus.Logger) error {
+				return app.PurgeDeletedCompanies(cmd.Context(), cfg, retention, logger)
+			})
+		},
+	}
+	purgeCmd.Flags().StringVar(&olderThan, "older-than", "30d", "purge companies deleted longer ago than this, e.g. 30d or 12h")
+	return purgeCmd
+}
+
+func NewOpenAPICommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "openapi",
+		Short: "Print the OpenAPI document of the HTTP API",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			doc, err := rest.OpenAPIDocument()
+			if err != nil {
+				return err
+			}
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(doc)
+		},
+	}
+}
+
+func NewConfigCommand() *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the configuration",
+	}
+	configCmd.AddCommand(NewConfigPrintCommand())
+	return configCmd
+}
+
+func NewConfigPrintCommand() *cobra.Command {
+	var redact bool
+	cmd := &cobra.Command{
+		Use:   "print",
+		Short: "Print the effective configuration and the source of each value",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			cfg := app.NewConfig()
+			if err := app.ReadConfig(configPath, cfg); err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			_, _ = fmt.Fprintln(w, "KEY\tVALUE\tSOURCE")
+			for _, v := range app.EffectiveConfig(redact) {
+				value, err := json.Marshal(v.Value)
+				if err != nil {
+					return fmt.Errorf("encode %s: %w", v.Key, err)
+				}
+				_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", v.Key, value, v.Source)
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+
+			// the invalid values are printed too, so their source can be found
+			return cfg.Validate()
+		},
+	}
+	cmd.Flags().BoolVar(&redact, "redact", false, "hide the passwords, secrets and tokens")
+	return cmd
+}
+
+// parseRetention parses a duration that, on top of time.ParseDuration units, accepts days, e.g. "30d".
+func parseRetention(s string) (time.Duration, error) {
+	var retention time.Duration
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid --older-than value %q: %w", s, err)
+		}
+		retention = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if retention, err = time.ParseDuration(s); err != nil {
+			return 0, fmt.Errorf("invalid --older-than value %q: %w", s, err)
+		}
+	}
+
+	if retention < 0 {
+		return 0, fmt.Errorf("invalid --older-than value %q: must not be negative", s)
+	}
+	return retention, nil
+}
+
 func main() {
-	if err := NewRootCommand().Execute(); err != nil {
+	// the services shut down gracefully on the first signal, a second one kills the process
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	err := NewRootCommand().ExecuteContext(ctx)
+	stop()
+	if err != nil {
 		log.Fatal(err)
 	}
 }
@@ -3,15 +3,38 @@ package app
 
 import (
 	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"net/http"
+	"time"
 
+	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
 	"github.com/sirupsen/logrus"
 
+	"github.com/faeelol/companies-store/internal/app/consumer"
 	"github.com/faeelol/companies-store/internal/app/database"
+	"github.com/faeelol/companies-store/internal/app/database/repositories"
+	"github.com/faeelol/companies-store/internal/app/events"
+	"github.com/faeelol/companies-store/internal/app/grpcapi"
+	"github.com/faeelol/companies-store/internal/app/health"
 	"github.com/faeelol/companies-store/internal/app/kafka"
+	"github.com/faeelol/companies-store/internal/app/logic/companies"
+	"github.com/faeelol/companies-store/internal/app/logic/idempotency"
+	"github.com/faeelol/companies-store/internal/app/logic/outbox"
+	"github.com/faeelol/companies-store/internal/app/logic/republish"
+	"github.com/faeelol/companies-store/internal/app/logic/stream"
+	"github.com/faeelol/companies-store/internal/app/logic/webhooks"
+	"github.com/faeelol/companies-store/internal/app/metrics"
 	"github.com/faeelol/companies-store/internal/app/rest"
+	"github.com/faeelol/companies-store/internal/app/rest/middlewares"
+	"github.com/faeelol/companies-store/internal/app/sinks"
+	"github.com/faeelol/companies-store/internal/app/tracing"
 )
 
+const purgeBatchSize = 500
+
 func LoadConfigInitLoggerAndDo(configPath string, cfg *Config, f func(logger *logrus.Logger) error) error {
 	err := LoadConfig(configPath, cfg)
 	if err != nil {
@@ -20,6 +43,18 @@ func LoadConfigInitLoggerAndDo(configPath string, cfg *Config, f func(logger *lo
 
 	logger := InitLogger()
 
+	shutdownTracing, err := tracing.Init(context.Background(), cfg.Tracing, logger)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			logger.WithField("error", err).Error("failed to flush the spans")
+		}
+	}()
+
 	return f(logger)
 }
 
@@ -31,22 +66,395 @@ func InitLogger() *logrus.Logger {
 	return logger
 }
 
-func StartHTTPService(ctx context.Context, cfg *Config, logger *logrus.Logger) error {
+type service interface {
+	Start(ctx context.Context, logger *logrus.Logger) error
+}
+
+// StartHTTPService serves the REST API, and the gRPC API next to it when withGRPC is set.
+func StartHTTPService(ctx context.Context, cfg *Config, logger *logrus.Logger, withGRPC bool) error {
+	db, err := database.GetDB(cfg.DB)
+	if err != nil {
+		return err
+	}
+	defer closeDB(db, logger)
+
+	checker := newHealthChecker(cfg, db, nil, relayKafkaTopics(cfg))
+
+	// the gRPC changes reach the event streams too
+	broker := stream.NewBroker(cfg.Stream)
+	services := []service{rest.NewServer(cfg.Server, logger, db, events.NewBuilder(cfg.Events), broker, checker)}
+	if withGRPC {
+		services = append(services, newGRPCServer(cfg, logger, db, broker, checker))
+	}
+	background, closeSinks, err := backgroundServices(cfg, logger, db, checker)
+	if err != nil {
+		return err
+	}
+	defer closeSinks()
+	services = append(services, background...)
+
+	return runDrainedServices(ctx, logger, checker, services...)
+}
+
+func StartGRPCService(ctx context.Context, cfg *Config, logger *logrus.Logger) error {
 	db, err := database.GetDB(cfg.DB)
 	if err != nil {
 		return err
 	}
+	defer closeDB(db, logger)
 
-	kafkaProducer := kafka.NewProducer(cfg.Kafka)
-	defer func(kProducer *kafka.Producer) {
-		_ = kProducer.Close()
-	}(kafkaProducer)
+	checker := newHealthChecker(cfg, db, nil, relayKafkaTopics(cfg))
 
-	server := rest.NewServer(cfg.Server, logger, db, kafkaProducer)
+	services := []service{newGRPCServer(cfg, logger, db, nil, checker)}
+	background, closeSinks, err := backgroundServices(cfg, logger, db, checker)
+	if err != nil {
+		return err
+	}
+	defer closeSinks()
+	services = append(services, background...)
 
-	return server.Start(ctx, logger)
+	return runDrainedServices(ctx, logger, checker, services...)
+}
+
+// backgroundServices returns the services running next to the APIs: the outbox relay and the
+// webhook worker when they run in the process, and the admin listener. closeSinks closes the event
+// sinks of the relay, once the services are stopped.
+func backgroundServices(
+	cfg *Config,
+	logger *logrus.Logger,
+	db *sqlx.DB,
+	checker *health.Checker,
+) (services []service, closeSinks func(), err error) {
+	closeSinks = func() {}
+	if cfg.Outbox.RelayInProcess {
+		eventSinks, err := sinks.New(cfg.Sinks, cfg.Kafka, logger)
+		if err != nil {
+			return nil, nil, err
+		}
+		closeSinks = func() {
+			closeEventSinks(eventSinks, logger)
+		}
+
+		services = append(services, newOutboxRelay(cfg, db, eventSinks))
+	}
+	if cfg.Webhooks.WorkerInProcess {
+		services = append(services, newWebhookWorker(cfg, db))
+	}
+	if cfg.Metrics.Addr != "" {
+		metrics.RegisterCompanyCounter(newCompaniesController(cfg, db, nil))
+		services = append(services, newMetricsServer(cfg, cfg.Metrics.Addr, checker))
+	}
+	return services, closeSinks, nil
+}
+
+// RunOutboxRelay publishes the outbox to the event sinks, serving the relay metrics on the metrics address.
+func RunOutboxRelay(ctx context.Context, cfg *Config, logger *logrus.Logger) error {
+	db, err := database.GetDB(cfg.DB)
+	if err != nil {
+		return err
+	}
+	defer closeDB(db, logger)
+
+	eventSinks, err := sinks.New(cfg.Sinks, cfg.Kafka, logger)
+	if err != nil {
+		return err
+	}
+	defer closeEventSinks(eventSinks, logger)
+
+	checker := newHealthChecker(cfg, db, cfg.Sinks.KafkaTopics(cfg.Kafka.Topic), nil)
+
+	return runDrainedServices(ctx, logger, checker,
+		newOutboxRelay(cfg, db, eventSinks),
+		newMetricsServer(cfg, cfg.Outbox.MetricsAddr, checker),
+	)
+}
+
+// RunWebhookWorker delivers the events to the webhook subscriptions, serving the worker metrics
+// on the metrics address.
+func RunWebhookWorker(ctx context.Context, cfg *Config, logger *logrus.Logger) error {
+	db, err := database.GetDB(cfg.DB)
+	if err != nil {
+		return err
+	}
+	defer closeDB(db, logger)
+
+	checker := newHealthChecker(cfg, db, nil, nil)
+
+	return runDrainedServices(ctx, logger, checker,
+		newWebhookWorker(cfg, db),
+		newMetricsServer(cfg, cfg.Webhooks.MetricsAddr, checker),
+	)
+}
+
+// RunCommandConsumer applies the company commands read from the commands topic.
+func RunCommandConsumer(ctx context.Context, cfg *Config, logger *logrus.Logger) error {
+	db, err := database.GetDB(cfg.DB)
+	if err != nil {
+		return err
+	}
+	defer closeDB(db, logger)
+
+	commands := kafka.NewConsumer(cfg.Kafka, cfg.Consumer.CommandsTopic, cfg.Consumer.GroupID)
+	defer func(commands *kafka.Consumer) {
+		_ = commands.Close()
+	}(commands)
+
+	replies := kafka.NewTopicProducer(cfg.Kafka, cfg.Consumer.RepliesTopic)
+	defer closeProducer(replies, logger)
+
+	deadLetters := kafka.NewTopicProducer(cfg.Kafka, cfg.Consumer.DeadLetterTopic)
+	defer closeProducer(deadLetters, logger)
+
+	checker := newHealthChecker(cfg, db, []string{cfg.Consumer.CommandsTopic}, relayKafkaTopics(cfg))
+
+	services := []service{
+		consumer.NewConsumer(
+			cfg.Consumer,
+			commands,
+			replies,
+			deadLetters,
+			newCompaniesController(cfg, db, nil),
+			idempotency.NewStore(
+				db,
+				repositories.NewIdempotencyRepository(),
+				cfg.Server.IdempotencyTTL,
+				cfg.Server.IdempotencyLockTimeout,
+			),
+		),
+	}
+	background, closeSinks, err := backgroundServices(cfg, logger, db, checker)
+	if err != nil {
+		return err
+	}
+	defer closeSinks()
+	services = append(services, background...)
+
+	return runDrainedServices(ctx, logger, checker, services...)
+}
+
+// RepublishCompanies publishes a snapshot event of every company matching the filter to the topic,
+// the events topic when it's empty.
+func RepublishCompanies(
+	ctx context.Context,
+	cfg *Config,
+	logger *logrus.Logger,
+	topic string,
+	opts republish.Options,
+) error {
+	db, err := database.GetDB(cfg.DB)
+	if err != nil {
+		return err
+	}
+	defer closeDB(db, logger)
+
+	if topic == "" {
+		topic = cfg.Kafka.Topic
+	}
+	kafkaProducer := kafka.NewTopicProducer(cfg.Kafka, topic)
+	defer closeProducer(kafkaProducer, logger)
+
+	republisher := republish.NewRepublisher(
+		db,
+		repositories.NewCompanyRepository(),
+		events.NewBuilder(cfg.Events),
+		kafkaProducer,
+	)
+
+	runLogger := logger.WithField("topic", topic)
+	published, err := republisher.Run(ctx, runLogger, opts)
+	runLogger.WithField("published", published).Info("republished company snapshots")
+
+	return err
+}
+
+func newCompaniesController(cfg *Config, db *sqlx.DB, notifier companies.ChangeNotifier) *companies.Controller {
+	return companies.NewCompaniesController(
+		db,
+		repositories.NewCompanyRepository(),
+		repositories.NewRevisionRepository(),
+		repositories.NewOutboxRepository(),
+		repositories.NewWebhookRepository(),
+		events.NewBuilder(cfg.Events),
+		notifier,
+	)
+}
+
+func newGRPCServer(
+	cfg *Config,
+	logger *logrus.Logger,
+	db *sqlx.DB,
+	notifier companies.ChangeNotifier,
+	checker *health.Checker,
+) *grpcapi.Server {
+	return grpcapi.NewServer(cfg.GRPC, cfg.Server.JWT, logger, newCompaniesController(cfg, db, notifier), checker)
+}
+
+func newWebhookWorker(cfg *Config, db *sqlx.DB) *webhooks.Worker {
+	return webhooks.NewWorker(cfg.Webhooks, db, repositories.NewWebhookRepository())
+}
+
+func newOutboxRelay(cfg *Config, db *sqlx.DB, publisher outbox.Publisher) *outbox.Relay {
+	return outbox.NewRelay(cfg.Outbox, db, repositories.NewOutboxRepository(), publisher)
+}
+
+// closeEventSinks flushes and closes the event sinks. Like the other resources, they're closed once the
+// services stopped, in the reverse order they were opened, so the database pool is closed last.
+func closeEventSinks(eventSinks *sinks.Fanout, logger *logrus.Logger) {
+	if err := eventSinks.Close(); err != nil {
+		logger.WithField("error", err).Error("failed to close the event sinks")
+		return
+	}
+	logger.Info("flushed and closed the event sinks")
+}
+
+func closeProducer(producer *kafka.Producer, logger *logrus.Logger) {
+	if err := producer.Close(); err != nil {
+		logger.WithField("error", err).Error("failed to close the kafka producer")
+		return
+	}
+	logger.Info("flushed and closed the kafka producer")
+}
+
+func closeDB(db *sqlx.DB, logger *logrus.Logger) {
+	stats := db.Stats()
+	if err := db.Close(); err != nil {
+		logger.WithField("error", err).Error("failed to close the database pool")
+		return
+	}
+	logger.WithFields(logrus.Fields{
+		"open_connections": stats.OpenConnections,
+		"in_use":           stats.InUse,
+	}).Info("closed the database pool")
+}
+
+// newHealthChecker checks the database, and the Kafka topics the process can't work without.
+// The optionalTopics are only reported, their outage doesn't make the process not ready.
+func newHealthChecker(cfg *Config, db *sqlx.DB, kafkaTopics, optionalTopics []string) *health.Checker {
+	checks := []health.Check{
+		{Name: "postgres", Run: db.PingContext},
+		{Name: "migrations", Run: func(ctx context.Context) error {
+			return database.CheckMigrations(ctx, db)
+		}},
+	}
+	for _, topic := range kafkaTopics {
+		checks = append(checks, kafkaCheck(cfg, topic, false))
+	}
+	for _, topic := range optionalTopics {
+		checks = append(checks, kafkaCheck(cfg, topic, true))
+	}
+	return health.NewChecker(cfg.Health, checks...)
+}
+
+func kafkaCheck(cfg *Config, topic string, optional bool) health.Check {
+	return health.Check{
+		Name: "kafka:" + topic,
+		Run: func(ctx context.Context) error {
+			return kafka.CheckTopic(ctx, cfg.Kafka, topic)
+		},
+		Optional: optional,
+	}
+}
+
+// relayKafkaTopics returns the topics the outbox relay of the process publishes to. They don't gate
+// the readiness of the APIs, the outbox holds the events while Kafka is down.
+func relayKafkaTopics(cfg *Config) []string {
+	if !cfg.Outbox.RelayInProcess {
+		return nil
+	}
+	return cfg.Sinks.KafkaTopics(cfg.Kafka.Topic)
+}
+
+// metricsServer serves the Prometheus metrics, the expvar ones, and the health of the process.
+type metricsServer struct {
+	addr    string
+	checker *health.Checker
+	// shutdownTimeout bounds the wait for the scrapes and probes in flight on shutdown.
+	shutdownTimeout time.Duration
+}
+
+// newMetricsServer returns the admin listener on addr, which drains like the HTTP server does.
+func newMetricsServer(cfg *Config, addr string, checker *health.Checker) metricsServer {
+	return metricsServer{addr: addr, checker: checker, shutdownTimeout: cfg.Server.ShutdownTimeout}
+}
+
+func (s metricsServer) Start(ctx context.Context, logger *logrus.Logger) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	mux.Handle("/debug/vars", expvar.Handler())
+	mux.Handle("/healthz", s.checker.LivenessHandler())
+	mux.Handle("/readyz", s.checker.ReportHandler())
+	httpServer := &http.Server{Addr: s.addr, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+
+	errChan := make(chan error, 1)
+	go func() {
+		logger.WithField("addr", s.addr).Info("starting metrics server")
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errChan <- fmt.Errorf("metrics server error: %w", err)
+		}
+		close(errChan)
+	}()
+
+	select {
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+		defer cancel()
+		return httpServer.Shutdown(shutdownCtx)
+	case err := <-errChan:
+		return err
+	}
+}
+
+// runServices runs the services until ctx is done or one of them fails, which stops the others.
+func runServices(ctx context.Context, logger *logrus.Logger, services ...service) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errChan := make(chan error, len(services))
+	for _, svc := range services {
+		go func(svc service) {
+			err := svc.Start(ctx, logger)
+			cancel()
+			errChan <- err
+		}(svc)
+	}
+
+	var errs []error
+	for range services {
+		if err := <-errChan; err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// runDrainedServices runs the services, the readiness failing for the shutdown delay before they stop.
+func runDrainedServices(ctx context.Context, logger *logrus.Logger, checker *health.Checker, services ...service) error {
+	drainCtx, cancel := checker.DrainContext(ctx, logger)
+	defer cancel()
+
+	err := runServices(drainCtx, logger, services...)
+	if ctx.Err() != nil {
+		logger.WithField("duration", checker.SinceDrain().String()).Info("services stopped")
+	}
+	return err
 }
 
 func MigrateDatabase(ctx context.Context, cfg *Config, direction string, logger *logrus.Logger) error {
 	return database.MigrateDatabase(ctx, cfg.DB, direction, logger)
 }
+
+func PurgeDeletedCompanies(ctx context.Context, cfg *Config, olderThan time.Duration, logger *logrus.Logger) error {
+	db, err := database.GetDB(cfg.DB)
+	if err != nil {
+		return err
+	}
+	defer closeDB(db, logger)
+
+	controller := newCompaniesController(cfg, db, nil)
+
+	ctx = middlewares.NewContextWithLogger(ctx, logger)
+	purged, err := controller.PurgeDeletedCompanies(ctx, olderThan, purgeBatchSize)
+	logger.WithField("purged", purged).Infof("purged companies deleted more than %s ago", olderThan)
+
+	return err
+}
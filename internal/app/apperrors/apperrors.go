@@ -6,28 +6,87 @@ import (
 	"net/http"
 )
 
+// Machine-readable error codes returned to the clients.
+const (
+	CodeBadRequest         = "bad_request"
+	CodeValidationFailed   = "validation_failed"
+	CodeNotFound           = "not_found"
+	CodeConflict           = "conflict"
+	CodeDuplicateCompany   = "duplicate_company"
+	CodeUnprocessable      = "unprocessable_entity"
+	CodeUnauthorized       = "unauthorized"
+	CodeForbidden          = "forbidden"
+	CodePreconditionFailed = "precondition_failed"
+	CodeVersionMismatch    = "version_mismatch"
+	CodeInternal           = "internal_error"
+	CodeUnavailable        = "service_unavailable"
+)
+
 type AppError struct {
-	Code    int    // HTTP-status
-	Message string // err msg
-	Err     error
+	Code      int          // HTTP-status
+	ErrorCode string       // machine-readable error code
+	Message   string       // err msg
+	Details   []FieldError // per-field errors
+	Err       error
+}
+
+// FieldError describes a problem with a single field of the request body.
+type FieldError struct {
+	Pointer string `json:"pointer"` // JSON pointer (RFC 6901) to the field
+	Message string `json:"message"`
 }
 
 func (e *AppError) Error() string {
 	return e.Message
 }
 
-func NewBadRequestError(message string) *AppError {
+func newAppError(status int, errorCode, message string) *AppError {
 	return &AppError{
-		Code:    http.StatusBadRequest,
-		Message: message,
+		Code:      status,
+		ErrorCode: errorCode,
+		Message:   message,
 	}
 }
 
+func NewBadRequestError(message string) *AppError {
+	return newAppError(http.StatusBadRequest, CodeBadRequest, message)
+}
+
 func NewNotFoundError(message string) *AppError {
-	return &AppError{
-		Code:    http.StatusNotFound,
-		Message: message,
-	}
+	return newAppError(http.StatusNotFound, CodeNotFound, message)
+}
+
+func NewConflictError(message string) *AppError {
+	return newAppError(http.StatusConflict, CodeConflict, message)
+}
+
+func NewUnprocessableError(message string) *AppError {
+	return newAppError(http.StatusUnprocessableEntity, CodeUnprocessable, message)
+}
+
+func NewUnauthorizedError(message string) *AppError {
+	return newAppError(http.StatusUnauthorized, CodeUnauthorized, message)
+}
+
+func NewForbiddenError(message string) *AppError {
+	return newAppError(http.StatusForbidden, CodeForbidden, message)
+}
+
+func NewPreconditionFailedError(message string) *AppError {
+	return newAppError(http.StatusPreconditionFailed, CodePreconditionFailed, message)
+}
+
+func NewInternalServerError(message string) *AppError {
+	return newAppError(http.StatusInternalServerError, CodeInternal, message)
+}
+
+func NewServiceUnavailableError(message string) *AppError {
+	return newAppError(http.StatusServiceUnavailable, CodeUnavailable, message)
+}
+
+// NewValidationError reports a request body that doesn't match its schema.
+func NewValidationError(message string, details []FieldError) *AppError {
+	return NewUnprocessableError(message).WithErrorCode(CodeValidationFailed).WithDetails(details)
 }
 
 func (e *AppError) WithCause(err error) *AppError {
@@ -35,11 +94,14 @@ func (e *AppError) WithCause(err error) *AppError {
 	return e
 }
 
-func NewInternalServerError(message string) *AppError {
-	return &AppError{
-		Code:    http.StatusInternalServerError,
-		Message: message,
-	}
+func (e *AppError) WithErrorCode(errorCode string) *AppError {
+	e.ErrorCode = errorCode
+	return e
+}
+
+func (e *AppError) WithDetails(details []FieldError) *AppError {
+	e.Details = details
+	return e
 }
 
 func MapToAppError(err error) *AppError {
@@ -1,56 +1,353 @@
 package app
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
-	"path/filepath"
+	"net/url"
+	"os"
+	"sort"
 	"strings"
 
 	"github.com/spf13/viper"
 
+	"github.com/faeelol/companies-store/internal/app/consumer"
 	"github.com/faeelol/companies-store/internal/app/database"
+	"github.com/faeelol/companies-store/internal/app/events"
+	"github.com/faeelol/companies-store/internal/app/grpcapi"
+	"github.com/faeelol/companies-store/internal/app/health"
 	"github.com/faeelol/companies-store/internal/app/kafka"
+	"github.com/faeelol/companies-store/internal/app/logic/outbox"
+	"github.com/faeelol/companies-store/internal/app/logic/stream"
+	"github.com/faeelol/companies-store/internal/app/logic/webhooks"
+	"github.com/faeelol/companies-store/internal/app/metrics"
 	"github.com/faeelol/companies-store/internal/app/rest"
+	"github.com/faeelol/companies-store/internal/app/sinks"
+	"github.com/faeelol/companies-store/internal/app/tracing"
 )
 
+// EnvPrefix prefixes the environment variables overriding the config keys, COMPANIES_DATABASE_PASSWORD
+// overrides database.password. The variable suffixed with _FILE, COMPANIES_DATABASE_PASSWORD_FILE, names
+// a file the value is read from instead, for the secrets mounted as files.
+const EnvPrefix = "COMPANIES"
+
+const envFileSuffix = "_FILE"
+
+const redacted = "[REDACTED]"
+
+// legacyKeys are the keys replaced by another one, they still apply when the new key isn't set.
+var legacyKeys = map[string]string{
+	"database.name": "database.dbname",
+}
+
+// envFiles are the variables read from the files of their _FILE variable.
+var envFiles = map[string]string{}
+
+// ConfigError lists all the problems of the configuration.
+type ConfigError struct {
+	Problems []string
+}
+
+func (e *ConfigError) Error() string {
+	return "invalid config:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+func newConfigError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ConfigError{Problems: problems(err)}
+}
+
+// problems flattens the joined errors.
+func problems(err error) []string {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var list []string
+		for _, err := range joined.Unwrap() {
+			list = append(list, problems(err)...)
+		}
+		return list
+	}
+	return []string{err.Error()}
+}
+
 type Config struct {
-	Server *rest.Config
-	DB     *database.Config
-	Kafka  *kafka.Config
+	Server   *rest.Config
+	GRPC     *grpcapi.Config
+	DB       *database.Config
+	Kafka    *kafka.Config
+	Outbox   *outbox.Config
+	Events   *events.Config
+	Consumer *consumer.Config
+	Sinks    *sinks.Config
+	Webhooks *webhooks.Config
+	Stream   *stream.Config
+	Metrics  *metrics.Config
+	Tracing  *tracing.Config
+	Health   *health.Config
 }
 
 func NewConfig() *Config {
 	return &Config{}
 }
 
+// LoadConfig reads the configuration and validates it.
 func LoadConfig(cfgPath string, cfg *Config) error {
+	if err := ReadConfig(cfgPath, cfg); err != nil {
+		return err
+	}
+	return cfg.Validate()
+}
+
+// ReadConfig reads the configuration from the file, when cfgPath isn't empty, and the environment.
+func ReadConfig(cfgPath string, cfg *Config) error {
 	if err := loadGlobalConfig(cfgPath); err != nil {
-		log.Fatalf("Error loading config: %v", err)
+		return err
 	}
+	applyLegacyKeys()
 
 	cfg.Server = rest.LoadServerConfig()
+	cfg.GRPC = grpcapi.LoadGRPCConfig()
 	cfg.DB = database.LoadDatabaseConfig()
 	cfg.Kafka = kafka.LoadKafkaConfig()
+	cfg.Outbox = outbox.LoadOutboxConfig()
+	cfg.Events = events.LoadEventsConfig()
+	cfg.Consumer = consumer.LoadConsumerConfig()
+	cfg.Webhooks = webhooks.LoadWebhooksConfig()
+	cfg.Stream = stream.LoadStreamConfig()
+	cfg.Metrics = metrics.LoadMetricsConfig()
+	cfg.Tracing = tracing.LoadTracingConfig()
+	cfg.Health = health.LoadHealthConfig()
+
+	var err error
+	if cfg.Sinks, err = sinks.LoadSinksConfig(); err != nil {
+		return newConfigError(err)
+	}
 
 	return nil
 }
 
+// Validate checks the whole configuration, the error lists every problem found.
+func (c *Config) Validate() error {
+	return newConfigError(errors.Join(
+		c.Server.Validate(),
+		c.GRPC.Validate(),
+		c.DB.Validate(),
+		c.Kafka.Validate(),
+		c.Outbox.Validate(),
+		c.Events.Validate(),
+		c.Consumer.Validate(),
+		c.Sinks.Validate(),
+		c.Webhooks.Validate(),
+		c.Stream.Validate(),
+		c.Tracing.Validate(),
+		c.Health.Validate(),
+		checkLegacyKeys(),
+		checkEnv(),
+	))
+}
+
 func loadGlobalConfig(configPath string) error {
-	dir := filepath.Dir(configPath)
-	file := filepath.Base(configPath)
+	viper.SetEnvPrefix(EnvPrefix)
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	viper.AutomaticEnv()
 
-	filename := strings.TrimSuffix(file, filepath.Ext(file))
-	ext := filepath.Ext(file)[1:]
+	if err := loadEnvFiles(); err != nil {
+		return newConfigError(err)
+	}
 
-	// setup Viper
-	viper.SetConfigName(filename)
-	viper.SetConfigType(ext)
-	viper.AddConfigPath(dir)
+	// the configuration can come from the environment alone
+	if configPath == "" {
+		return nil
+	}
 
-	// read config
+	viper.SetConfigFile(configPath)
 	if err := viper.ReadInConfig(); err != nil {
 		return fmt.Errorf("error reading config: %w", err)
 	}
 
 	return nil
 }
+
+// loadEnvFiles sets the variables from the files named by their _FILE variable, so they're read like
+// the other ones.
+func loadEnvFiles() error {
+	var errs []error
+	for _, env := range os.Environ() {
+		fileVar, path, _ := strings.Cut(env, "=")
+		name, ok := strings.CutSuffix(fileVar, envFileSuffix)
+		if !ok || !strings.HasPrefix(name, EnvPrefix+"_") {
+			continue
+		}
+		if _, set := os.LookupEnv(name); set && envFiles[name] == "" {
+			errs = append(errs, fmt.Errorf("%s and %s are both set", name, fileVar))
+			continue
+		}
+
+		value, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", fileVar, err))
+			continue
+		}
+		if err := os.Setenv(name, strings.TrimRight(string(value), "\r\n")); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", fileVar, err))
+			continue
+		}
+		envFiles[name] = fileVar
+	}
+	return errors.Join(errs...)
+}
+
+func applyLegacyKeys() {
+	for key, legacy := range legacyKeys {
+		if !isExplicit(key) && viper.IsSet(legacy) {
+			viper.Set(key, viper.Get(legacy))
+		}
+	}
+}
+
+func checkLegacyKeys() error {
+	var errs []error
+	for key, legacy := range legacyKeys {
+		if isExplicit(key) && viper.IsSet(legacy) {
+			errs = append(errs, fmt.Errorf("%s and its legacy key %s are both set, remove %s", key, legacy, legacy))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkEnv reports the prefixed variables matching no key, likely misspelled.
+func checkEnv() error {
+	known := map[string]bool{}
+	for _, key := range viper.AllKeys() {
+		known[envName(key)] = true
+	}
+	for _, legacy := range legacyKeys {
+		known[envName(legacy)] = true
+	}
+
+	var names []string
+	for _, env := range os.Environ() {
+		name, _, _ := strings.Cut(env, "=")
+		if !strings.HasPrefix(name, EnvPrefix+"_") || known[name] {
+			continue
+		}
+		// a _FILE variable is checked through the variable read from its file
+		if base, ok := strings.CutSuffix(name, envFileSuffix); ok && envFiles[base] != "" {
+			continue
+		}
+		if fileVar, ok := envFiles[name]; ok {
+			name = fileVar
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, name := range names {
+		errs = append(errs, fmt.Errorf("%s doesn't match any config key", name))
+	}
+	return errors.Join(errs...)
+}
+
+func envName(key string) string {
+	return EnvPrefix + "_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// isExplicit tells whether the key is set in the file or the environment.
+func isExplicit(key string) bool {
+	_, inEnv := os.LookupEnv(envName(key))
+	return inEnv || viper.InConfig(key)
+}
+
+// ConfigValue is a value of the effective configuration.
+type ConfigValue struct {
+	Key   string
+	Value any
+	// Source is where the value comes from: default, file <path> or env <variable>.
+	Source string
+}
+
+// EffectiveConfig returns the values read by ReadConfig, sorted by key. When redact is set, the secrets
+// are hidden: the passwords, secrets and tokens, the headers of the sinks and the passwords of their URLs.
+func EffectiveConfig(redact bool) []ConfigValue {
+	legacy := map[string]bool{}
+	for _, key := range legacyKeys {
+		legacy[key] = true
+	}
+
+	keys := viper.AllKeys()
+	sort.Strings(keys)
+
+	values := make([]ConfigValue, 0, len(keys))
+	for _, key := range keys {
+		if legacy[key] {
+			continue
+		}
+		value := viper.Get(key)
+		if redact {
+			value = redactValue(key, value)
+		}
+		values = append(values, ConfigValue{Key: key, Value: value, Source: valueSource(key)})
+	}
+	return values
+}
+
+func valueSource(key string) string {
+	name := envName(key)
+	if fileVar, ok := envFiles[name]; ok {
+		return "env " + fileVar
+	}
+	if _, ok := os.LookupEnv(name); ok {
+		return "env " + name
+	}
+	if viper.InConfig(key) {
+		return "file " + viper.ConfigFileUsed()
+	}
+	if legacy, ok := legacyKeys[key]; ok && viper.IsSet(legacy) {
+		return valueSource(legacy) + " (" + legacy + ")"
+	}
+	return "default"
+}
+
+func redactValue(key string, value any) any {
+	if key == "sinks" {
+		return redactSinks(value)
+	}
+
+	name := key[strings.LastIndex(key, ".")+1:]
+	for _, secret := range []string{"password", "secret", "token"} {
+		if strings.Contains(name, secret) && value != "" {
+			return redacted
+		}
+	}
+	return value
+}
+
+func redactSinks(value any) any {
+	// the list is a []any from the file and a []map[string]any otherwise
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return redacted
+	}
+	var list []map[string]any
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return redacted
+	}
+
+	for _, sink := range list {
+		if headers, ok := sink["headers"].(map[string]any); ok {
+			for name := range headers {
+				headers[name] = redacted
+			}
+		}
+		if rawURL, ok := sink["url"].(string); ok {
+			if u, err := url.Parse(rawURL); err == nil {
+				sink["url"] = u.Redacted()
+			} else {
+				sink["url"] = redacted
+			}
+		}
+	}
+	return list
+}
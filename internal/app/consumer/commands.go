@@ -0,0 +1,188 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/faeelol/companies-store/internal/app/apperrors"
+	"github.com/faeelol/companies-store/internal/app/logic/companies"
+	"github.com/faeelol/companies-store/internal/app/model"
+	"github.com/faeelol/companies-store/internal/app/rest/handlers"
+	"github.com/faeelol/companies-store/internal/app/rest/problem"
+)
+
+type CommandType string
+
+const (
+	CommandCreate CommandType = "create"
+	CommandUpdate CommandType = "update"
+	CommandDelete CommandType = "delete"
+)
+
+const maxCorrelationIDLength = 200
+
+// Command is a company change sent to the commands topic. Data is validated with the schema
+// of the matching endpoint: the create and patch bodies, or the id or name of the company to delete.
+type Command struct {
+	CorrelationID   string          `json:"correlation_id"`
+	Type            CommandType     `json:"type"`
+	ExpectedVersion *int            `json:"expected_version,omitempty"`
+	Data            json.RawMessage `json:"data"`
+
+	// decoded data
+	company    model.CreateCompanyData
+	updates    model.UpdateCompanyData
+	deleteID   uuid.UUID
+	deleteName string
+}
+
+type deleteCommandData struct {
+	ID   *uuid.UUID `json:"id"`
+	Name *string    `json:"name"`
+}
+
+type ReplyStatus string
+
+const (
+	ReplySucceeded ReplyStatus = "succeeded"
+	ReplyFailed    ReplyStatus = "failed"
+)
+
+// Reply reports the outcome of a command to the replies topic. Company is the created or
+// updated company, Error the problem details of a failed command.
+type Reply struct {
+	CorrelationID string           `json:"correlation_id"`
+	Type          CommandType      `json:"type"`
+	Status        ReplyStatus      `json:"status"`
+	Company       *model.Company   `json:"company,omitempty"`
+	Error         *problem.Details `json:"error,omitempty"`
+}
+
+// newSucceededReply reports the company the command created or updated; a delete reports none.
+func newSucceededReply(cmd Command, company *model.Company) Reply {
+	if cmd.Type == CommandDelete {
+		company = nil
+	}
+	return Reply{CorrelationID: cmd.CorrelationID, Type: cmd.Type, Status: ReplySucceeded, Company: company}
+}
+
+func newFailedReply(cmd Command, err error) Reply {
+	details := problem.FromAppError(apperrors.MapToAppError(err))
+	return Reply{CorrelationID: cmd.CorrelationID, Type: cmd.Type, Status: ReplyFailed, Error: &details}
+}
+
+// decodeCommand parses and validates the command. The returned command carries the correlation id
+// even when it's invalid, as long as it could be read.
+func decodeCommand(value []byte) (Command, error) {
+	var cmd Command
+	if err := json.Unmarshal(value, &cmd); err != nil {
+		return Command{}, apperrors.NewBadRequestError("failed to parse JSON command").WithCause(err)
+	}
+
+	var details []apperrors.FieldError
+	if cmd.CorrelationID == "" {
+		details = append(details, apperrors.FieldError{Pointer: "/correlation_id", Message: "correlation_id is required"})
+	} else if len(cmd.CorrelationID) > maxCorrelationIDLength {
+		details = append(details, apperrors.FieldError{
+			Pointer: "/correlation_id",
+			Message: fmt.Sprintf("correlation_id must not exceed %d characters", maxCorrelationIDLength),
+		})
+		cmd.CorrelationID = ""
+	}
+	switch cmd.Type {
+	case CommandCreate, CommandUpdate, CommandDelete:
+	default:
+		details = append(details, apperrors.FieldError{Pointer: "/type", Message: "type must be one of create, update, delete"})
+	}
+	if cmd.ExpectedVersion != nil && cmd.Type == CommandCreate {
+		details = append(details, apperrors.FieldError{
+			Pointer: "/expected_version",
+			Message: "expected_version is not allowed for create",
+		})
+	}
+	if len(cmd.Data) == 0 {
+		details = append(details, apperrors.FieldError{Pointer: "/data", Message: "data is required"})
+	}
+	if len(details) > 0 {
+		return cmd, apperrors.NewValidationError("command doesn't match the schema", details)
+	}
+
+	if err := cmd.decodeData(); err != nil {
+		return cmd, err
+	}
+	return cmd, nil
+}
+
+func (cmd *Command) decodeData() error {
+	var err error
+	switch cmd.Type {
+	case CommandCreate:
+		cmd.company, err = handlers.DecodeCreateCompany(cmd.Data)
+		return dataError(err)
+	case CommandUpdate:
+		cmd.updates, err = handlers.DecodePatchCompany(cmd.Data)
+		return dataError(err)
+	case CommandDelete:
+		var data deleteCommandData
+		if err := json.Unmarshal(cmd.Data, &data); err != nil {
+			return apperrors.NewValidationError("command doesn't match the schema", []apperrors.FieldError{
+				{Pointer: "/data", Message: "data must be an object with the id or the name of the company"},
+			})
+		}
+		switch {
+		case data.ID != nil:
+			cmd.deleteID = *data.ID
+		case data.Name != nil && *data.Name != "":
+			cmd.deleteName = *data.Name
+		default:
+			return apperrors.NewValidationError("command doesn't match the schema", []apperrors.FieldError{
+				{Pointer: "/data", Message: "id or name should be provided"},
+			})
+		}
+		return nil
+	default:
+		return apperrors.NewBadRequestError(fmt.Sprintf("unknown command type: %s", cmd.Type))
+	}
+}
+
+// execute applies the decoded command through the controller.
+func execute(ctx context.Context, cc CompaniesController, cmd Command) (*model.Company, error) {
+	switch cmd.Type {
+	case CommandCreate:
+		created, err := cc.CreateCompany(ctx, cmd.company)
+		if err != nil {
+			return nil, err
+		}
+		return &created, nil
+	case CommandUpdate:
+		updated, err := cc.UpdateCompany(ctx, cmd.updates, companies.ExpectedVersion(cmd.ExpectedVersion))
+		if err != nil {
+			return nil, err
+		}
+		return &updated, nil
+	case CommandDelete:
+		return nil, cc.DeleteCompany(ctx, cmd.deleteID, cmd.deleteName, companies.ExpectedVersion(cmd.ExpectedVersion))
+	default:
+		return nil, apperrors.NewBadRequestError(fmt.Sprintf("unknown command type: %s", cmd.Type))
+	}
+}
+
+// dataError points the field errors of the data at the data member of the command.
+func dataError(err error) error {
+	if err == nil {
+		return nil
+	}
+	appErr := apperrors.MapToAppError(err)
+	if len(appErr.Details) == 0 {
+		return appErr
+	}
+
+	details := make([]apperrors.FieldError, 0, len(appErr.Details))
+	for _, d := range appErr.Details {
+		details = append(details, apperrors.FieldError{Pointer: "/data" + d.Pointer, Message: d.Message})
+	}
+	return appErr.WithDetails(details)
+}
@@ -0,0 +1,61 @@
+package consumer
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+type Config struct {
+	CommandsTopic   string
+	RepliesTopic    string
+	DeadLetterTopic string
+	GroupID         string
+	// MinBackoff and MaxBackoff bound the delay between the retries of a command failing
+	// with a server error, or of a reply that can't be published.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+func LoadConsumerConfig() *Config {
+	viper.SetDefault("consumer.commands_topic", "company_commands")
+	viper.SetDefault("consumer.replies_topic", "company_command_replies")
+	viper.SetDefault("consumer.dead_letter_topic", "company_commands_dlq")
+	viper.SetDefault("consumer.group_id", "companies-store")
+	viper.SetDefault("consumer.min_backoff", "1s")
+	viper.SetDefault("consumer.max_backoff", "30s")
+
+	return &Config{
+		CommandsTopic:   viper.GetString("consumer.commands_topic"),
+		RepliesTopic:    viper.GetString("consumer.replies_topic"),
+		DeadLetterTopic: viper.GetString("consumer.dead_letter_topic"),
+		GroupID:         viper.GetString("consumer.group_id"),
+		MinBackoff:      viper.GetDuration("consumer.min_backoff"),
+		MaxBackoff:      viper.GetDuration("consumer.max_backoff"),
+	}
+}
+
+func (c *Config) Validate() error {
+	var errs []error
+	if c.CommandsTopic == "" {
+		errs = append(errs, errors.New("consumer.commands_topic is required"))
+	}
+	if c.RepliesTopic == "" {
+		errs = append(errs, errors.New("consumer.replies_topic is required"))
+	}
+	if c.DeadLetterTopic == "" {
+		errs = append(errs, errors.New("consumer.dead_letter_topic is required"))
+	}
+	if c.GroupID == "" {
+		errs = append(errs, errors.New("consumer.group_id is required"))
+	}
+	if c.MinBackoff <= 0 {
+		errs = append(errs, fmt.Errorf("consumer.min_backoff must be positive, got %s", c.MinBackoff))
+	}
+	if c.MaxBackoff < c.MinBackoff {
+		errs = append(errs, fmt.Errorf("consumer.max_backoff must be at least consumer.min_backoff, got %s", c.MaxBackoff))
+	}
+	return errors.Join(errs...)
+}
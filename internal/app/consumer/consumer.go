@@ -0,0 +1,321 @@
+// Package consumer applies company commands read from Kafka, for clients writing asynchronously.
+package consumer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/faeelol/companies-store/internal/app/apperrors"
+	"github.com/faeelol/companies-store/internal/app/events"
+	"github.com/faeelol/companies-store/internal/app/kafka"
+	"github.com/faeelol/companies-store/internal/app/logic/companies"
+	"github.com/faeelol/companies-store/internal/app/model"
+	"github.com/faeelol/companies-store/internal/app/rest/middlewares"
+	"github.com/faeelol/companies-store/internal/app/tracing"
+)
+
+// Headers of the replies and the dead letters.
+const (
+	HeaderCorrelationID    = "x-correlation-id"
+	HeaderDeadLetterReason = "x-dead-letter-reason"
+	HeaderOriginalTopic    = "x-original-topic"
+	HeaderOriginalPart     = "x-original-partition"
+	HeaderOriginalOffset   = "x-original-offset"
+)
+
+// Subject is the acting subject of the changes made by the commands. Commands are not authenticated,
+// so the actor header of a command is only logged and never trusted as the subject.
+const Subject = "kafka-consumer"
+
+type CompaniesController interface {
+	CreateCompany(ctx context.Context, company model.CreateCompanyData) (model.Company, error)
+	UpdateCompany(ctx context.Context, updates model.UpdateCompanyData, expectedVersions []int) (model.Company, error)
+	DeleteCompany(ctx context.Context, reqUUID uuid.UUID, name string, expectedVersions []int) error
+}
+
+type CommandsReader interface {
+	Fetch(ctx context.Context) (kafka.Message, error)
+	Commit(ctx context.Context, msg kafka.Message) error
+}
+
+type Publisher interface {
+	Publish(ctx context.Context, key string, value []byte, headers map[string]string) error
+}
+
+// ReplyStore keeps the replies of the applied commands under their correlation id, see idempotency.Store.
+type ReplyStore interface {
+	Lookup(ctx context.Context, scope, key, requestHash string) (*middlewares.StoredResponse, error)
+	Save(ctx context.Context, scope, key, requestHash string, resp middlewares.StoredResponse) error
+	SaveWithin(ctx context.Context, tx *sqlx.Tx, scope, key, requestHash string, resp middlewares.StoredResponse) error
+}
+
+// Consumer applies the commands one by one, in the order of their partition. The offset of a command
+// is only committed once it's been applied and its reply published, so a command may be read more than
+// once; its reply is stored under its correlation id in the transaction of the change, and a command
+// read again is answered with the stored reply instead of being applied twice. Commands failing with
+// a server error are retried until they succeed; invalid commands are moved to the dead-letter topic.
+type Consumer struct {
+	cfg         *Config
+	commands    CommandsReader
+	replies     Publisher
+	deadLetters Publisher
+	cc          CompaniesController
+	store       ReplyStore
+}
+
+func NewConsumer(
+	cfg *Config,
+	commands CommandsReader,
+	replies, deadLetters Publisher,
+	cc CompaniesController,
+	store ReplyStore,
+) *Consumer {
+	return &Consumer{
+		cfg:         cfg,
+		commands:    commands,
+		replies:     replies,
+		deadLetters: deadLetters,
+		cc:          cc,
+		store:       store,
+	}
+}
+
+type outgoingMessage struct {
+	publisher Publisher
+	key       string
+	value     []byte
+	headers   map[string]string
+}
+
+// Start consumes the commands until ctx is done.
+func (c *Consumer) Start(ctx context.Context, logger *logrus.Logger) error {
+	logger.WithField("topic", c.cfg.CommandsTopic).Info("starting commands consumer")
+
+	for {
+		msg, err := c.commands.Fetch(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				logger.Info("commands consumer stopped")
+				return nil
+			}
+			return fmt.Errorf("failed to fetch command: %w", err)
+		}
+
+		msgLogger := logger.WithFields(logrus.Fields{
+			"topic":     msg.Topic,
+			"partition": msg.Partition,
+			"offset":    msg.Offset,
+		})
+
+		// the command continues the trace of its producer
+		msgCtx, span := tracing.Tracer().Start(tracing.Extract(ctx, msg.Headers), msg.Topic+" process",
+			trace.WithSpanKind(trace.SpanKindConsumer),
+			trace.WithAttributes(
+				semconv.MessagingSystemKafka,
+				semconv.MessagingOperationTypeDeliver,
+				semconv.MessagingDestinationName(msg.Topic),
+				semconv.MessagingDestinationPartitionID(strconv.Itoa(msg.Partition)),
+				semconv.MessagingKafkaMessageOffset(int(msg.Offset)),
+				semconv.MessagingKafkaConsumerGroup(c.cfg.GroupID),
+			),
+		)
+		err = c.handle(msgCtx, msgLogger, msg)
+		tracing.End(span, err)
+		if err != nil {
+			// only the end of ctx stops the retries, the uncommitted command is read again on restart
+			logger.Info("commands consumer stopped")
+			return nil
+		}
+	}
+}
+
+// handle applies the command, publishes the messages about it and commits it.
+func (c *Consumer) handle(ctx context.Context, logger logrus.FieldLogger, msg kafka.Message) error {
+	outgoing, err := c.process(ctx, logger, msg)
+	if err != nil {
+		return err
+	}
+	for _, out := range outgoing {
+		err = c.retry(ctx, logger, "publish", func() error {
+			return out.publisher.Publish(ctx, out.key, out.value, out.headers)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return c.retry(ctx, logger, "commit", func() error {
+		return c.commands.Commit(ctx, msg)
+	})
+}
+
+// process applies the command and returns the messages to publish about it.
+func (c *Consumer) process(ctx context.Context, logger logrus.FieldLogger, msg kafka.Message) ([]outgoingMessage, error) {
+	cmd, err := decodeCommand(msg.Value)
+	if err != nil {
+		logger.WithField("error", err).Warn("invalid command moved to the dead-letter topic")
+
+		outgoing := []outgoingMessage{c.deadLetter(msg, err)}
+		if cmd.CorrelationID != "" {
+			outgoing = append(outgoing, c.reply(newFailedReply(cmd, err)))
+		}
+		return outgoing, nil
+	}
+
+	cmdCtx := commandContext(ctx, logger, msg, cmd)
+	requestHash := hashCommand(msg.Value)
+
+	var stored *middlewares.StoredResponse
+	var company *model.Company
+	var cmdErr error
+	err = c.retry(ctx, logger, "apply command", func() error {
+		var err error
+		stored, err = c.store.Lookup(ctx, Subject, cmd.CorrelationID, requestHash)
+		if errors.Is(err, middlewares.ErrIdempotencyKeyReused) {
+			cmdErr = apperrors.NewUnprocessableError("correlation_id has already been used for a different command").
+				WithErrorCode(middlewares.CodeIdempotencyKeyReused)
+			return nil
+		}
+		if err != nil || stored != nil {
+			return err
+		}
+
+		company, cmdErr = execute(c.withStoredReply(cmdCtx, cmd, requestHash), c.cc, cmd)
+		if cmdErr == nil {
+			return nil
+		}
+		if apperrors.MapToAppError(cmdErr).Code >= http.StatusInternalServerError {
+			return cmdErr
+		}
+		// a failed command changes nothing, so its reply is stored on its own
+		return c.store.Save(ctx, Subject, cmd.CorrelationID, requestHash, storedReply(newFailedReply(cmd, cmdErr)))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case stored != nil:
+		logger.WithField("correlation_id", cmd.CorrelationID).Info("command already applied, its reply is sent again")
+		return []outgoingMessage{c.replyMessage(cmd.CorrelationID, stored.Body)}, nil
+	case cmdErr != nil:
+		return []outgoingMessage{c.reply(newFailedReply(cmd, cmdErr))}, nil
+	default:
+		return []outgoingMessage{c.reply(newSucceededReply(cmd, company))}, nil
+	}
+}
+
+// withStoredReply makes the change applying the command store its reply in the same transaction.
+func (c *Consumer) withStoredReply(ctx context.Context, cmd Command, requestHash string) context.Context {
+	return companies.NewContextWithCommitHook(ctx, func(ctx context.Context, tx *sqlx.Tx, company model.Company) error {
+		reply := storedReply(newSucceededReply(cmd, &company))
+		return c.store.SaveWithin(ctx, tx, Subject, cmd.CorrelationID, requestHash, reply)
+	})
+}
+
+// hashCommand fingerprints the command, so a correlation id reused for another command can be detected.
+func hashCommand(value []byte) string {
+	h := sha256.Sum256(value)
+	return hex.EncodeToString(h[:])
+}
+
+// storedReply is the reply as it's stored under the correlation id of its command.
+func storedReply(reply Reply) middlewares.StoredResponse {
+	resp := middlewares.StoredResponse{StatusCode: http.StatusOK}
+	if reply.Error != nil {
+		resp.StatusCode = reply.Error.Status
+	}
+	// a reply is made of a few fields that always marshal
+	resp.Body, _ = json.Marshal(reply)
+	return resp
+}
+
+// commandContext carries the correlation id as the request id and the consumer as the acting subject,
+// so they end up in the revisions and the events of the change. The unverified actor header is only logged.
+func commandContext(ctx context.Context, logger logrus.FieldLogger, msg kafka.Message, cmd Command) context.Context {
+	logger = logger.WithField("correlation_id", cmd.CorrelationID)
+	if actor := msg.Headers[events.HeaderActor]; actor != "" {
+		logger = logger.WithField("claimed_actor", actor)
+	}
+	ctx = middlewares.NewContextWithRequestID(ctx, cmd.CorrelationID)
+	ctx = middlewares.NewContextWithLogger(ctx, logger)
+	return middlewares.NewContextWithClaims(ctx, jwt.MapClaims{"sub": Subject})
+}
+
+func (c *Consumer) reply(reply Reply) outgoingMessage {
+	// a reply is made of a few fields that always marshal
+	value, _ := json.Marshal(reply)
+	return c.replyMessage(reply.CorrelationID, value)
+}
+
+func (c *Consumer) replyMessage(correlationID string, value []byte) outgoingMessage {
+	return outgoingMessage{
+		publisher: c.replies,
+		key:       correlationID,
+		value:     value,
+		headers: map[string]string{
+			events.HeaderContentType: "application/json",
+			HeaderCorrelationID:      correlationID,
+		},
+	}
+}
+
+func (c *Consumer) deadLetter(msg kafka.Message, reason error) outgoingMessage {
+	headers := make(map[string]string, len(msg.Headers)+4)
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	headers[HeaderDeadLetterReason] = deadLetterReason(reason)
+	headers[HeaderOriginalTopic] = msg.Topic
+	headers[HeaderOriginalPart] = strconv.Itoa(msg.Partition)
+	headers[HeaderOriginalOffset] = strconv.FormatInt(msg.Offset, 10)
+
+	return outgoingMessage{
+		publisher: c.deadLetters,
+		key:       string(msg.Key),
+		value:     msg.Value,
+		headers:   headers,
+	}
+}
+
+func deadLetterReason(err error) string {
+	appErr := apperrors.MapToAppError(err)
+	reason := appErr.Message
+	for _, d := range appErr.Details {
+		reason += fmt.Sprintf("; %s: %s", d.Pointer, d.Message)
+	}
+	return reason
+}
+
+// retry calls f until it succeeds, doubling the delay between the attempts. It only gives up
+// when ctx is done.
+func (c *Consumer) retry(ctx context.Context, logger logrus.FieldLogger, action string, f func() error) error {
+	delay := c.cfg.MinBackoff
+	for {
+		err := f()
+		if err == nil {
+			return nil
+		}
+		logger.WithField("error", err).WithField("retry_in", delay.String()).Errorf("failed to %s", action)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, c.cfg.MaxBackoff)
+	}
+}
@@ -1,11 +1,18 @@
 package database
 
 import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/spf13/viper"
 )
 
+// sslModes are the sslmode values of lib/pq.
+var sslModes = []string{"disable", "require", "verify-ca", "verify-full"}
+
 type Config struct {
 	Dialect       string
 	Host          string
@@ -46,3 +53,29 @@ func LoadDatabaseConfig() *Config {
 		MigrationsDir: viper.GetString("database.migrations_dir"),
 	}
 }
+
+func (c *Config) Validate() error {
+	var errs []error
+	if c.Dialect != "postgres" {
+		errs = append(errs, fmt.Errorf("database.dialect must be postgres, got %q", c.Dialect))
+	}
+	if c.Host == "" {
+		errs = append(errs, errors.New("database.host is required"))
+	}
+	if c.Port < 1 || c.Port > 65535 {
+		errs = append(errs, fmt.Errorf("database.port must be between 1 and 65535, got %d", c.Port))
+	}
+	if c.User == "" {
+		errs = append(errs, errors.New("database.user is required"))
+	}
+	if c.Database == "" {
+		errs = append(errs, errors.New("database.name is required"))
+	}
+	if !slices.Contains(sslModes, c.SSLMode) {
+		errs = append(errs, fmt.Errorf("database.sslmode must be one of %s, got %q", strings.Join(sslModes, ", "), c.SSLMode))
+	}
+	if c.Timeout <= 0 {
+		errs = append(errs, fmt.Errorf("database.timeout must be positive, got %s", c.Timeout))
+	}
+	return errors.Join(errs...)
+}
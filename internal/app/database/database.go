@@ -2,10 +2,18 @@
 package database
 
 import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
 	"fmt"
 	"sync"
 
+	"github.com/XSAM/otelsql"
 	"github.com/jmoiron/sqlx"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+
+	"github.com/faeelol/companies-store/internal/app/metrics"
+	"github.com/faeelol/companies-store/internal/app/tracing"
 )
 
 var (
@@ -22,14 +30,34 @@ func GetDB(cfg *Config) (*sqlx.DB, error) {
 			cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.Database, cfg.SSLMode,
 		)
 
-		dbInstance, err = sqlx.Connect(cfg.Dialect, dsn)
+		// the queries made within a trace are recorded as spans
+		var sqlDB *sql.DB
+		sqlDB, err = otelsql.Open(cfg.Dialect, dsn,
+			otelsql.WithAttributes(semconv.DBSystemPostgreSQL),
+			otelsql.WithSpanOptions(otelsql.SpanOptions{
+				OmitConnResetSession: true,
+				OmitConnPrepare:      true,
+				OmitRows:             true,
+				SpanFilter: func(ctx context.Context, _ otelsql.Method, _ string, _ []driver.NamedValue) bool {
+					return tracing.InTrace(ctx)
+				},
+			}),
+		)
 		if err != nil {
 			return
 		}
+		db := sqlx.NewDb(sqlDB, cfg.Dialect)
+		if err = db.Ping(); err != nil {
+			_ = db.Close()
+			return
+		}
+		dbInstance = db
 
 		dbInstance.SetMaxOpenConns(25)
 		dbInstance.SetMaxIdleConns(25)
 		dbInstance.SetConnMaxLifetime(5 * 60)
+
+		metrics.RegisterDB(dbInstance.DB, cfg.Database)
 	})
 
 	return dbInstance, err
@@ -5,12 +5,22 @@ import (
 	"fmt"
 
 	"github.com/jmoiron/sqlx"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/faeelol/companies-store/internal/app/tracing"
 )
 
 type TxFunc func(tx *sqlx.Tx) error
 
-// WithinTransaction runs the provided function within a transaction.
-func WithinTransaction(ctx context.Context, db *sqlx.DB, fn TxFunc) error {
+// WithinTransaction runs the provided function within a transaction. Within a trace, the transaction
+// is recorded as a span, next to the spans of its queries.
+func WithinTransaction(ctx context.Context, db *sqlx.DB, fn TxFunc) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "db.transaction", trace.WithAttributes(semconv.DBSystemPostgreSQL))
+	defer func() {
+		tracing.End(span, err)
+	}()
+
 	tx, err := db.BeginTxx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
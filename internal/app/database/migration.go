@@ -3,7 +3,9 @@ package database
 import (
 	"context"
 	"fmt"
+	"strings"
 
+	"github.com/jmoiron/sqlx"
 	"github.com/rubenv/sql-migrate"
 	"github.com/sirupsen/logrus"
 
@@ -39,3 +41,28 @@ func MigrateDatabase(ctx context.Context, cfg *Config, direction string, logger
 	logger.Infof("Applied %d migrations in direction '%s'\n", applied, direction)
 	return nil
 }
+
+// CheckMigrations fails when a migration of the binary isn't applied to the database. Migrations
+// the binary doesn't know about are fine: they're applied ahead of a rollout.
+func CheckMigrations(ctx context.Context, db *sqlx.DB) error {
+	var appliedIDs []string
+	if err := db.SelectContext(ctx, &appliedIDs, `SELECT id FROM gorp_migrations`); err != nil {
+		return fmt.Errorf("read applied migrations: %w", err)
+	}
+
+	applied := make(map[string]struct{}, len(appliedIDs))
+	for _, id := range appliedIDs {
+		applied[id] = struct{}{}
+	}
+
+	var missing []string
+	for _, m := range migrations.Migrations.Migrations {
+		if _, ok := applied[m.Id]; !ok {
+			missing = append(missing, m.Id)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("migrations not applied: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
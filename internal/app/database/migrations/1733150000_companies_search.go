@@ -0,0 +1,32 @@
+package migrations
+
+import "github.com/rubenv/sql-migrate"
+
+func NewMigration1733150000CompaniesSearch() *migrate.Migration {
+	return &migrate.Migration{
+		Id: "1733150000_companies_search.go",
+		Up: []string{
+			`
+			CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+			ALTER TABLE companies ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+				setweight(to_tsvector('english', coalesce(name, '')), 'A') ||
+				setweight(to_tsvector('english', coalesce(description, '')), 'B')
+			) STORED;
+
+			CREATE INDEX companies_search_vector_idx ON companies USING GIN (search_vector);
+
+			CREATE INDEX companies_name_trgm_idx ON companies USING GIN (name gin_trgm_ops);
+			`,
+		},
+		Down: []string{
+			`
+			DROP INDEX IF EXISTS companies_name_trgm_idx;
+
+			DROP INDEX IF EXISTS companies_search_vector_idx;
+
+			ALTER TABLE companies DROP COLUMN IF EXISTS search_vector;
+			`,
+		},
+	}
+}
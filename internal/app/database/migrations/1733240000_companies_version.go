@@ -0,0 +1,19 @@
+package migrations
+
+import "github.com/rubenv/sql-migrate"
+
+func NewMigration1733240000CompaniesVersion() *migrate.Migration {
+	return &migrate.Migration{
+		Id: "1733240000_companies_version.go",
+		Up: []string{
+			`
+			ALTER TABLE companies ADD COLUMN version INT NOT NULL DEFAULT 1;
+			`,
+		},
+		Down: []string{
+			`
+			ALTER TABLE companies DROP COLUMN IF EXISTS version;
+			`,
+		},
+	}
+}
@@ -0,0 +1,30 @@
+package migrations
+
+import "github.com/rubenv/sql-migrate"
+
+func NewMigration1733330000CompanyRevisions() *migrate.Migration {
+	return &migrate.Migration{
+		Id: "1733330000_company_revisions.go",
+		Up: []string{
+			`
+			CREATE TABLE company_revisions (
+				id BIGSERIAL PRIMARY KEY,
+				company_id UUID NOT NULL,
+				operation VARCHAR(16) NOT NULL,
+				version INT NOT NULL,
+				snapshot JSONB NOT NULL,
+				actor TEXT,
+				request_id TEXT,
+				created_at TIMESTAMPTZ NOT NULL DEFAULT clock_timestamp()
+			);
+
+			CREATE INDEX company_revisions_company_id_idx ON company_revisions (company_id, created_at, id);
+			`,
+		},
+		Down: []string{
+			`
+			DROP TABLE IF EXISTS company_revisions;
+			`,
+		},
+	}
+}
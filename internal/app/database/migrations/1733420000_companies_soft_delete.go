@@ -0,0 +1,33 @@
+package migrations
+
+import "github.com/rubenv/sql-migrate"
+
+func NewMigration1733420000CompaniesSoftDelete() *migrate.Migration {
+	return &migrate.Migration{
+		Id: "1733420000_companies_soft_delete.go",
+		Up: []string{
+			`
+			ALTER TABLE companies ADD COLUMN deleted_at TIMESTAMP;
+
+			ALTER TABLE companies DROP CONSTRAINT companies_name_key;
+
+			CREATE UNIQUE INDEX companies_name_active_key ON companies (name) WHERE deleted_at IS NULL;
+
+			CREATE INDEX companies_deleted_at_idx ON companies (deleted_at) WHERE deleted_at IS NOT NULL;
+			`,
+		},
+		Down: []string{
+			`
+			DELETE FROM companies WHERE deleted_at IS NOT NULL;
+
+			DROP INDEX IF EXISTS companies_deleted_at_idx;
+
+			DROP INDEX IF EXISTS companies_name_active_key;
+
+			ALTER TABLE companies ADD CONSTRAINT companies_name_key UNIQUE (name);
+
+			ALTER TABLE companies DROP COLUMN IF EXISTS deleted_at;
+			`,
+		},
+	}
+}
@@ -0,0 +1,25 @@
+package migrations
+
+import "github.com/rubenv/sql-migrate"
+
+func NewMigration1733510000CompanyNameAliases() *migrate.Migration {
+	return &migrate.Migration{
+		Id: "1733510000_company_name_aliases.go",
+		Up: []string{
+			`
+			CREATE TABLE company_name_aliases (
+				name VARCHAR(15) PRIMARY KEY,
+				company_id UUID NOT NULL REFERENCES companies (id) ON DELETE CASCADE,
+				created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+			);
+
+			CREATE INDEX company_name_aliases_company_id_idx ON company_name_aliases (company_id);
+			`,
+		},
+		Down: []string{
+			`
+			DROP TABLE IF EXISTS company_name_aliases;
+			`,
+		},
+	}
+}
@@ -0,0 +1,31 @@
+package migrations
+
+import "github.com/rubenv/sql-migrate"
+
+func NewMigration1733600000IdempotencyKeys() *migrate.Migration {
+	return &migrate.Migration{
+		Id: "1733600000_idempotency_keys.go",
+		Up: []string{
+			`
+			CREATE TABLE idempotency_keys (
+				scope TEXT NOT NULL,
+				key VARCHAR(255) NOT NULL,
+				request_hash CHAR(64) NOT NULL,
+				status_code INT,
+				response_headers JSONB,
+				response_body BYTEA,
+				created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+				expires_at TIMESTAMPTZ NOT NULL,
+				PRIMARY KEY (scope, key)
+			);
+
+			CREATE INDEX idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);
+			`,
+		},
+		Down: []string{
+			`
+			DROP TABLE IF EXISTS idempotency_keys;
+			`,
+		},
+	}
+}
@@ -0,0 +1,31 @@
+package migrations
+
+import "github.com/rubenv/sql-migrate"
+
+func NewMigration1733690000Outbox() *migrate.Migration {
+	return &migrate.Migration{
+		Id: "1733690000_outbox.go",
+		Up: []string{
+			`
+			CREATE TABLE outbox (
+				id BIGSERIAL PRIMARY KEY,
+				key TEXT NOT NULL,
+				payload BYTEA NOT NULL,
+				attempts INT NOT NULL DEFAULT 0,
+				last_error TEXT,
+				next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+				created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+				sent_at TIMESTAMPTZ
+			);
+
+			CREATE INDEX outbox_pending_idx ON outbox (id) WHERE sent_at IS NULL;
+			CREATE INDEX outbox_sent_at_idx ON outbox (sent_at) WHERE sent_at IS NOT NULL;
+			`,
+		},
+		Down: []string{
+			`
+			DROP TABLE IF EXISTS outbox;
+			`,
+		},
+	}
+}
@@ -0,0 +1,25 @@
+package migrations
+
+import "github.com/rubenv/sql-migrate"
+
+func NewMigration1733780000OutboxHeaders() *migrate.Migration {
+	return &migrate.Migration{
+		Id: "1733780000_outbox_headers.go",
+		Up: []string{
+			`
+			ALTER TABLE outbox ADD COLUMN headers JSONB NOT NULL DEFAULT '{}'::jsonb;
+
+			-- tombstones have no payload
+			ALTER TABLE outbox ALTER COLUMN payload DROP NOT NULL;
+			`,
+		},
+		Down: []string{
+			`
+			DELETE FROM outbox WHERE payload IS NULL;
+			ALTER TABLE outbox ALTER COLUMN payload SET NOT NULL;
+
+			ALTER TABLE outbox DROP COLUMN IF EXISTS headers;
+			`,
+		},
+	}
+}
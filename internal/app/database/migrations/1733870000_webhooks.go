@@ -0,0 +1,52 @@
+package migrations
+
+import "github.com/rubenv/sql-migrate"
+
+func NewMigration1733870000Webhooks() *migrate.Migration {
+	return &migrate.Migration{
+		Id: "1733870000_webhooks.go",
+		Up: []string{
+			`
+			CREATE TABLE webhook_subscriptions (
+				id UUID PRIMARY KEY,
+				url TEXT NOT NULL,
+				-- no event types means all of them
+				event_types TEXT[] NOT NULL DEFAULT '{}',
+				secret TEXT NOT NULL,
+				description TEXT NOT NULL DEFAULT '',
+				enabled BOOLEAN NOT NULL DEFAULT TRUE,
+				disabled_reason TEXT,
+				consecutive_failures INT NOT NULL DEFAULT 0,
+				created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+				updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+			);
+
+			CREATE TABLE webhook_deliveries (
+				id BIGSERIAL PRIMARY KEY,
+				subscription_id UUID NOT NULL REFERENCES webhook_subscriptions (id) ON DELETE CASCADE,
+				event_id TEXT NOT NULL,
+				event_type TEXT NOT NULL,
+				payload BYTEA NOT NULL,
+				status TEXT NOT NULL DEFAULT 'pending',
+				attempts INT NOT NULL DEFAULT 0,
+				response_status INT,
+				last_error TEXT,
+				next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+				last_attempt_at TIMESTAMPTZ,
+				created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+				finished_at TIMESTAMPTZ
+			);
+
+			CREATE INDEX webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+			CREATE INDEX webhook_deliveries_subscription_idx ON webhook_deliveries (subscription_id, id);
+			CREATE INDEX webhook_deliveries_finished_at_idx ON webhook_deliveries (finished_at) WHERE finished_at IS NOT NULL;
+			`,
+		},
+		Down: []string{
+			`
+			DROP TABLE IF EXISTS webhook_deliveries;
+			DROP TABLE IF EXISTS webhook_subscriptions;
+			`,
+		},
+	}
+}
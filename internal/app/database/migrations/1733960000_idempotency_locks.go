@@ -0,0 +1,22 @@
+package migrations
+
+import "github.com/rubenv/sql-migrate"
+
+func NewMigration1733960000IdempotencyLocks() *migrate.Migration {
+	return &migrate.Migration{
+		Id: "1733960000_idempotency_locks.go",
+		Up: []string{
+			`
+			-- set while a request holds the key, refreshed as it runs
+			ALTER TABLE idempotency_keys ADD COLUMN locked_at TIMESTAMPTZ;
+
+			UPDATE idempotency_keys SET locked_at = created_at WHERE status_code IS NULL;
+			`,
+		},
+		Down: []string{
+			`
+			ALTER TABLE idempotency_keys DROP COLUMN IF EXISTS locked_at;
+			`,
+		},
+	}
+}
@@ -6,5 +6,15 @@ import "github.com/rubenv/sql-migrate"
 var Migrations = &migrate.MemoryMigrationSource{
 	Migrations: []*migrate.Migration{
 		NewMigration1732452571InitialMigration(),
+		NewMigration1733150000CompaniesSearch(),
+		NewMigration1733240000CompaniesVersion(),
+		NewMigration1733330000CompanyRevisions(),
+		NewMigration1733420000CompaniesSoftDelete(),
+		NewMigration1733510000CompanyNameAliases(),
+		NewMigration1733600000IdempotencyKeys(),
+		NewMigration1733690000Outbox(),
+		NewMigration1733780000OutboxHeaders(),
+		NewMigration1733870000Webhooks(),
+		NewMigration1733960000IdempotencyLocks(),
 	},
 }
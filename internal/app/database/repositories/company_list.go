@@ -0,0 +1,241 @@
+package repositories
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/faeelol/companies-store/internal/app/apperrors"
+	"github.com/faeelol/companies-store/internal/app/model"
+)
+
+const cursorTimeLayout = "2006-01-02T15:04:05.999999"
+
+// sortColumns maps the allowed sort fields to the SQL cast used for the keyset comparison.
+var sortColumns = map[model.CompanySortField]string{
+	model.SortByName:           "::varchar",
+	model.SortByEmployeesCount: "::int",
+	model.SortByCreatedAt:      "::timestamp",
+	model.SortByUpdatedAt:      "::timestamp",
+}
+
+// listCursor points right after the last company of a page. It is bound to the
+// sort it was issued for, so a cursor can't be reused with a different ordering.
+type listCursor struct {
+	SortBy    model.CompanySortField `json:"s"`
+	SortOrder model.SortOrder        `json:"o"`
+	Value     string                 `json:"v"`
+	ID        uuid.UUID              `json:"id"`
+}
+
+func encodeCursor(c any) (string, error) {
+	raw, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func decodeCursor(s string, dst any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, dst)
+}
+
+func cursorValue(c Company, sortBy model.CompanySortField) string {
+	switch sortBy {
+	case model.SortByEmployeesCount:
+		return strconv.Itoa(c.EmployeesCount)
+	case model.SortByCreatedAt:
+		return c.CreatedAt.Format(cursorTimeLayout)
+	case model.SortByUpdatedAt:
+		return c.UpdatedAt.Format(cursorTimeLayout)
+	default:
+		return c.Name
+	}
+}
+
+// isValidCursorValue tells whether the value of a cursor parses as the sort field, so a tampered
+// cursor is rejected instead of failing the cast in the query.
+func isValidCursorValue(value string, sortBy model.CompanySortField) bool {
+	switch sortBy {
+	case model.SortByEmployeesCount:
+		_, err := strconv.ParseInt(value, 10, 32)
+		return err == nil
+	case model.SortByCreatedAt, model.SortByUpdatedAt:
+		_, err := time.Parse(cursorTimeLayout, value)
+		return err == nil
+	default:
+		// Postgres rejects NUL in text
+		return !strings.ContainsRune(value, 0)
+	}
+}
+
+func listFilterClauses(filter model.ListCompaniesFilter) ([]string, []any) {
+	clauses := []string{"deleted_at IS NULL"}
+	var args []any
+
+	if filter.Type != nil {
+		clauses = append(clauses, "type = ?")
+		args = append(args, *filter.Type)
+	}
+	if filter.Registered != nil {
+		clauses = append(clauses, "registered = ?")
+		args = append(args, *filter.Registered)
+	}
+	if filter.MinEmployeesCount != nil {
+		clauses = append(clauses, "employees_count >= ?")
+		args = append(args, *filter.MinEmployeesCount)
+	}
+	if filter.MaxEmployeesCount != nil {
+		clauses = append(clauses, "employees_count <= ?")
+		args = append(args, *filter.MaxEmployeesCount)
+	}
+
+	return clauses, args
+}
+
+func whereSQL(clauses []string) string {
+	return "WHERE " + strings.Join(clauses, " AND ")
+}
+
+// ListCompanies returns a page of companies matching the filter, ordered by the requested
+// field with the company id as a tie-breaker. Pagination is keyset based: the returned
+// cursor encodes the sort value and id of the last company on the page.
+func (r *companyRepository) ListCompanies(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	params model.ListCompaniesParams,
+) (model.CompaniesPage, error) {
+	cast, ok := sortColumns[params.SortBy]
+	if !ok {
+		return model.CompaniesPage{}, apperrors.NewBadRequestError(fmt.Sprintf("unsupported sort field: %s", params.SortBy))
+	}
+
+	direction, comparison := "ASC", ">"
+	if params.SortOrder == model.SortOrderDesc {
+		direction, comparison = "DESC", "<"
+	}
+
+	clauses, args := listFilterClauses(params.Filter)
+
+	var total *int
+	if params.WithTotal {
+		var count int
+		query := sqlx.Rebind(sqlx.DOLLAR, "SELECT COUNT(*) FROM companies "+whereSQL(clauses))
+		if err := tx.GetContext(ctx, &count, query, args...); err != nil {
+			return model.CompaniesPage{}, apperrors.NewInternalServerError("failed to count companies").WithCause(err)
+		}
+		total = &count
+	}
+
+	if params.Cursor != "" {
+		var cursor listCursor
+		err := decodeCursor(params.Cursor, &cursor)
+		if err != nil || cursor.SortBy != params.SortBy || cursor.SortOrder != params.SortOrder ||
+			!isValidCursorValue(cursor.Value, params.SortBy) {
+			return model.CompaniesPage{}, apperrors.NewBadRequestError("invalid cursor")
+		}
+		clauses = append(clauses, fmt.Sprintf("(%s, id) %s (?%s, ?)", params.SortBy, comparison, cast))
+		args = append(args, cursor.Value, cursor.ID)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM companies
+		%s
+		ORDER BY %s %s, id %s
+		LIMIT ?
+	`, companyColumns, whereSQL(clauses), params.SortBy, direction, direction)
+	// one extra row tells whether there is a next page
+	args = append(args, params.Limit+1)
+
+	var rows []Company
+	if err := tx.SelectContext(ctx, &rows, sqlx.Rebind(sqlx.DOLLAR, query), args...); err != nil {
+		return model.CompaniesPage{}, apperrors.NewInternalServerError("failed to list companies").WithCause(err)
+	}
+
+	page := model.CompaniesPage{
+		Companies: make([]model.Company, 0, len(rows)),
+		Total:     total,
+	}
+
+	if len(rows) > params.Limit {
+		rows = rows[:params.Limit]
+		last := rows[len(rows)-1]
+		next, err := encodeCursor(listCursor{
+			SortBy:    params.SortBy,
+			SortOrder: params.SortOrder,
+			Value:     cursorValue(last, params.SortBy),
+			ID:        last.ID,
+		})
+		if err != nil {
+			return model.CompaniesPage{}, apperrors.NewInternalServerError("failed to encode cursor").WithCause(err)
+		}
+		page.NextCursor = next
+	}
+
+	for _, row := range rows {
+		page.Companies = append(page.Companies, row.toDTO())
+	}
+
+	return page, nil
+}
+
+// ScanCompanies returns up to limit companies matching the filter with an id greater than afterID,
+// ordered by id, so the whole table can be walked page by page.
+func (r *companyRepository) ScanCompanies(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	filter model.SnapshotFilter,
+	afterID uuid.UUID,
+	limit int,
+) ([]model.Company, error) {
+	clauses := []string{"id > ?"}
+	args := []any{afterID}
+
+	if !filter.IncludeDeleted {
+		clauses = append(clauses, "deleted_at IS NULL")
+	}
+	if filter.Type != nil {
+		clauses = append(clauses, "type = ?")
+		args = append(args, *filter.Type)
+	}
+	if filter.UpdatedFrom != nil {
+		clauses = append(clauses, "updated_at >= ?")
+		args = append(args, *filter.UpdatedFrom)
+	}
+	if filter.UpdatedTo != nil {
+		clauses = append(clauses, "updated_at < ?")
+		args = append(args, *filter.UpdatedTo)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM companies
+		%s
+		ORDER BY id
+		LIMIT ?
+	`, companyColumns, whereSQL(clauses))
+	args = append(args, limit)
+
+	var rows []Company
+	if err := tx.SelectContext(ctx, &rows, sqlx.Rebind(sqlx.DOLLAR, query), args...); err != nil {
+		return nil, apperrors.NewInternalServerError("failed to scan companies").WithCause(err)
+	}
+
+	companies := make([]model.Company, 0, len(rows))
+	for _, row := range rows {
+		companies = append(companies, row.toDTO())
+	}
+	return companies, nil
+}
@@ -18,15 +18,38 @@ import (
 )
 
 type CompanyRepository interface {
-	CreateCompany(ctx context.Context, tx *sqlx.Tx, company *Company) error
+	CreateCompany(ctx context.Context, tx *sqlx.Tx, company *Company) (model.Company, error)
 	GetCompany(ctx context.Context, tx *sqlx.Tx, reqUUID uuid.UUID, name string) (model.Company, error)
-	DeleteCompany(ctx context.Context, tx *sqlx.Tx, reqUUID uuid.UUID, name string) error
-	UpdateCompany(ctx context.Context, tx *sqlx.Tx, updates model.UpdateCompanyData) error
+	GetCompanyForUpdate(ctx context.Context, tx *sqlx.Tx, reqUUID uuid.UUID, name string) (model.Company, error)
+	DeleteCompany(ctx context.Context, tx *sqlx.Tx, reqUUID uuid.UUID, name string) (model.Company, error)
+	GetDeletedCompanyForUpdate(ctx context.Context, tx *sqlx.Tx, reqUUID uuid.UUID) (model.Company, error)
+	RestoreCompany(ctx context.Context, tx *sqlx.Tx, reqUUID uuid.UUID) (model.Company, error)
+	PurgeCompanies(ctx context.Context, tx *sqlx.Tx, olderThan time.Duration, limit int) ([]model.Company, error)
+	UpdateCompany(ctx context.Context, tx *sqlx.Tx, updates model.UpdateCompanyData) (model.Company, error)
+	AddNameAlias(ctx context.Context, tx *sqlx.Tx, companyID uuid.UUID, oldName string, newName string) error
+	ListCompanies(ctx context.Context, tx *sqlx.Tx, params model.ListCompaniesParams) (model.CompaniesPage, error)
+	ScanCompanies(
+		ctx context.Context,
+		tx *sqlx.Tx,
+		filter model.SnapshotFilter,
+		afterID uuid.UUID,
+		limit int,
+	) ([]model.Company, error)
+	SearchCompanies(ctx context.Context, tx *sqlx.Tx, params model.SearchCompaniesParams) (model.CompanySearchPage, error)
+	CountCompaniesByType(ctx context.Context, tx *sqlx.Tx) (map[model.CompanyType]int, error)
+	UpsertCompany(
+		ctx context.Context,
+		tx *sqlx.Tx,
+		company *Company,
+		mode model.ConflictMode,
+	) (model.Company, model.BulkItemStatus, error)
 }
 
 type companyRepository struct {
 }
 
+const companyColumns = "id, name, description, employees_count, registered, type, version, created_at, updated_at, deleted_at"
+
 type CompanyType string
 
 const (
@@ -47,18 +70,28 @@ type Company struct {
 	EmployeesCount int         `db:"employees_count"`
 	Registered     bool        `db:"registered"`
 	Type           CompanyType `db:"type"`
+	Version        int         `db:"version"`
 	CreatedAt      time.Time   `db:"created_at"`
 	UpdatedAt      time.Time   `db:"updated_at"`
+	DeletedAt      *time.Time  `db:"deleted_at"`
 }
 
 func (c Company) toDTO() model.Company {
+	var description string
+	if c.Description != nil {
+		description = *c.Description
+	}
 	return model.Company{
 		ID:             c.ID,
 		Name:           c.Name,
-		Description:    *c.Description,
+		Description:    description,
 		EmployeesCount: c.EmployeesCount,
 		Registered:     c.Registered,
 		Type:           c.Type.toDTO(),
+		Version:        c.Version,
+		CreatedAt:      c.CreatedAt,
+		UpdatedAt:      c.UpdatedAt,
+		DeletedAt:      c.DeletedAt,
 	}
 }
 
@@ -67,26 +100,140 @@ func NewCompanyRepository() CompanyRepository {
 }
 
 // CreateCompany creates a new company in the database
-func (r *companyRepository) CreateCompany(ctx context.Context, tx *sqlx.Tx, company *Company) error {
+func (r *companyRepository) CreateCompany(ctx context.Context, tx *sqlx.Tx, company *Company) (model.Company, error) {
 	query := `
 		INSERT INTO companies (id, name, description, employees_count, registered, type, created_at, updated_at)
 		VALUES (:id, :name, :description, :employees_count, :registered, :type, :created_at, :updated_at)
-	`
+		RETURNING ` + companyColumns
 
 	company.CreatedAt = time.Now()
 	company.UpdatedAt = time.Now()
-	_, err := tx.NamedExecContext(ctx, query, company)
-	if err != nil {
 
-		var pgErr *pq.Error
-		if errors.As(err, &pgErr) {
-			if pgErr.Code == "23505" {
-				return apperrors.NewBadRequestError("duplicate key violation: unique constraint failed")
-			}
+	var created Company
+	if _, err := namedGet(ctx, tx, &created, query, company); err != nil {
+		return model.Company{}, mapUniqueViolation(err, "failed to create company")
+	}
+	return created.toDTO(), nil
+}
+
+// UpsertCompany inserts a company resolving id and name conflicts according to mode:
+// skip leaves the existing row untouched, fail reports the conflict as an error and
+// upsert overwrites the company with the same id.
+func (r *companyRepository) UpsertCompany(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	company *Company,
+	mode model.ConflictMode,
+) (model.Company, model.BulkItemStatus, error) {
+	switch mode {
+	case model.OnConflictFail:
+		created, err := r.CreateCompany(ctx, tx, company)
+		if err != nil {
+			return model.Company{}, model.BulkItemError, err
 		}
-		return apperrors.NewInternalServerError("failed to create company").WithCause(err)
+		return created, model.BulkItemCreated, nil
+	case model.OnConflictSkip:
+		return r.insertOrSkipCompany(ctx, tx, company)
+	case model.OnConflictUpsert:
+		return r.insertOrUpdateCompany(ctx, tx, company)
+	default:
+		return model.Company{}, model.BulkItemError, apperrors.NewBadRequestError(fmt.Sprintf("unknown conflict mode: %s", mode))
 	}
-	return nil
+}
+
+func (r *companyRepository) insertOrSkipCompany(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	company *Company,
+) (model.Company, model.BulkItemStatus, error) {
+	query := `
+		INSERT INTO companies (id, name, description, employees_count, registered, type, created_at, updated_at)
+		VALUES (:id, :name, :description, :employees_count, :registered, :type, :created_at, :updated_at)
+		ON CONFLICT DO NOTHING
+		RETURNING ` + companyColumns
+
+	company.CreatedAt = time.Now()
+	company.UpdatedAt = company.CreatedAt
+
+	var created Company
+	found, err := namedGet(ctx, tx, &created, query, company)
+	if err != nil {
+		return model.Company{}, model.BulkItemError, apperrors.NewInternalServerError("failed to create company").WithCause(err)
+	}
+	if !found {
+		return model.Company{}, model.BulkItemSkipped, nil
+	}
+
+	return created.toDTO(), model.BulkItemCreated, nil
+}
+
+func (r *companyRepository) insertOrUpdateCompany(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	company *Company,
+) (model.Company, model.BulkItemStatus, error) {
+	query := `
+		INSERT INTO companies (id, name, description, employees_count, registered, type, created_at, updated_at)
+		VALUES (:id, :name, :description, :employees_count, :registered, :type, :created_at, :updated_at)
+		ON CONFLICT (id) DO UPDATE SET
+			name = EXCLUDED.name,
+			description = EXCLUDED.description,
+			employees_count = EXCLUDED.employees_count,
+			registered = EXCLUDED.registered,
+			type = EXCLUDED.type,
+			version = companies.version + 1,
+			updated_at = EXCLUDED.updated_at
+		WHERE companies.deleted_at IS NULL
+		RETURNING ` + companyColumns + `, (xmax = 0) AS inserted`
+
+	company.CreatedAt = time.Now()
+	company.UpdatedAt = company.CreatedAt
+
+	var row struct {
+		Company
+		Inserted bool `db:"inserted"`
+	}
+	found, err := namedGet(ctx, tx, &row, query, company)
+	if err != nil {
+		return model.Company{}, model.BulkItemError, mapUniqueViolation(err, "failed to upsert company")
+	}
+	if !found {
+		// the conflicting row is soft-deleted, it has to be restored first
+		return model.Company{}, model.BulkItemError, apperrors.NewConflictError("company with this id is deleted")
+	}
+
+	if row.Inserted {
+		return row.toDTO(), model.BulkItemCreated, nil
+	}
+	return row.toDTO(), model.BulkItemUpdated, nil
+}
+
+// namedGet runs a named query and scans its first row into dest. It reports whether a row was returned.
+func namedGet(ctx context.Context, tx *sqlx.Tx, dest any, query string, arg any) (bool, error) {
+	rows, err := sqlx.NamedQueryContext(ctx, tx, query, arg)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = rows.Close()
+	}()
+
+	if !rows.Next() {
+		return false, rows.Err()
+	}
+	if err := rows.StructScan(dest); err != nil {
+		return false, err
+	}
+	return true, rows.Err()
+}
+
+func mapUniqueViolation(err error, message string) error {
+	var pgErr *pq.Error
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return apperrors.NewConflictError("duplicate key violation: unique constraint failed").
+			WithErrorCode(apperrors.CodeDuplicateCompany)
+	}
+	return apperrors.NewInternalServerError(message).WithCause(err)
 }
 
 // GetCompany retrieves a company from the database by UUID or name.
@@ -96,6 +243,26 @@ func (r *companyRepository) GetCompany(
 	tx *sqlx.Tx,
 	reqUUID uuid.UUID,
 	name string,
+) (model.Company, error) {
+	return r.getCompany(ctx, tx, reqUUID, name, false)
+}
+
+// GetCompanyForUpdate works like GetCompany but also locks the row until the end of the transaction.
+func (r *companyRepository) GetCompanyForUpdate(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	reqUUID uuid.UUID,
+	name string,
+) (model.Company, error) {
+	return r.getCompany(ctx, tx, reqUUID, name, true)
+}
+
+func (r *companyRepository) getCompany(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	reqUUID uuid.UUID,
+	name string,
+	forUpdate bool,
 ) (model.Company, error) {
 	var query string
 	var args []interface{}
@@ -103,22 +270,43 @@ func (r *companyRepository) GetCompany(
 	switch {
 	case reqUUID != uuid.Nil:
 		query = `
-			SELECT id, name, description, employees_count, registered, type, created_at, updated_at
+			SELECT ` + companyColumns + `
 			FROM companies
-			WHERE id = $1
+			WHERE id = $1 AND deleted_at IS NULL
 		`
 		args = append(args, reqUUID)
-	case name != "":
+	case name != "" && forUpdate:
 		query = `
-			SELECT id, name, description, employees_count, registered, type, created_at, updated_at
+			SELECT ` + companyColumns + `
 			FROM companies
-			WHERE name = $1
+			WHERE name = $1 AND deleted_at IS NULL
+		`
+		args = append(args, name)
+	case name != "":
+		// previous names of renamed companies still resolve, unless another company uses the name now
+		query = `
+			SELECT ` + companyColumns + ` FROM (
+				SELECT c.*, 0 AS priority
+				FROM companies c
+				WHERE c.name = $1 AND c.deleted_at IS NULL
+				UNION ALL
+				SELECT c.*, 1 AS priority
+				FROM company_name_aliases a
+				JOIN companies c ON c.id = a.company_id
+				WHERE a.name = $1 AND c.deleted_at IS NULL
+			) matches
+			ORDER BY priority
+			LIMIT 1
 		`
 		args = append(args, name)
 	default:
 		return model.Company{}, apperrors.NewBadRequestError("either uuid or name must be provided")
 	}
 
+	if forUpdate {
+		query += " FOR UPDATE"
+	}
+
 	var company Company
 	err := tx.GetContext(ctx, &company, query, args...)
 	if err != nil {
@@ -131,60 +319,159 @@ func (r *companyRepository) GetCompany(
 	return company.toDTO(), nil
 }
 
-// DeleteCompany removes a company from the database by UUID or name.
+// DeleteCompany soft-deletes a company by UUID or name and returns its final state.
 // If both are empty, returns an error.
 func (r *companyRepository) DeleteCompany(
 	ctx context.Context,
 	tx *sqlx.Tx,
 	reqUUID uuid.UUID,
 	name string,
-) error {
+) (model.Company, error) {
 	var query string
 	var args []interface{}
 
 	switch {
 	case reqUUID != uuid.Nil:
 		query = `
-			DELETE FROM companies
-			WHERE id = $1
-		`
+			UPDATE companies SET deleted_at = NOW(), version = version + 1, updated_at = NOW()
+			WHERE id = $1 AND deleted_at IS NULL
+			RETURNING ` + companyColumns
 		args = append(args, reqUUID)
 	case name != "":
 		query = `
-			DELETE FROM companies
-			WHERE name = $1
-		`
+			UPDATE companies SET deleted_at = NOW(), version = version + 1, updated_at = NOW()
+			WHERE name = $1 AND deleted_at IS NULL
+			RETURNING ` + companyColumns
 		args = append(args, name)
 	default:
-		return apperrors.NewBadRequestError("either uuid or name must be provided")
+		return model.Company{}, apperrors.NewBadRequestError("either uuid or name must be provided")
 	}
 
-	result, err := tx.ExecContext(ctx, query, args...)
+	var company Company
+	err := tx.GetContext(ctx, &company, query, args...)
 	if err != nil {
-		return apperrors.NewInternalServerError("failed to delete company").WithCause(err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.Company{}, apperrors.NewNotFoundError("company not found")
+		}
+		return model.Company{}, apperrors.NewInternalServerError("failed to delete company").WithCause(err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	return company.toDTO(), nil
+}
+
+// GetDeletedCompanyForUpdate retrieves a soft-deleted company and locks the row until the end of the transaction.
+func (r *companyRepository) GetDeletedCompanyForUpdate(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	reqUUID uuid.UUID,
+) (model.Company, error) {
+	query := `
+		SELECT ` + companyColumns + `
+		FROM companies
+		WHERE id = $1 AND deleted_at IS NOT NULL
+		FOR UPDATE
+	`
+
+	var company Company
+	err := tx.GetContext(ctx, &company, query, reqUUID)
 	if err != nil {
-		return apperrors.NewInternalServerError("failed to get rows affected").WithCause(err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.Company{}, apperrors.NewNotFoundError("deleted company not found")
+		}
+		return model.Company{}, apperrors.NewInternalServerError("failed to query company").WithCause(err)
 	}
 
-	if rowsAffected == 0 {
-		return apperrors.NewNotFoundError("company not found")
+	return company.toDTO(), nil
+}
+
+// RestoreCompany brings back a soft-deleted company.
+func (r *companyRepository) RestoreCompany(ctx context.Context, tx *sqlx.Tx, reqUUID uuid.UUID) (model.Company, error) {
+	query := `
+		UPDATE companies SET deleted_at = NULL, version = version + 1, updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NOT NULL
+		RETURNING ` + companyColumns
+
+	var company Company
+	err := tx.GetContext(ctx, &company, query, reqUUID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.Company{}, apperrors.NewNotFoundError("deleted company not found")
+		}
+		return model.Company{}, mapUniqueViolation(err, "failed to restore company")
 	}
 
-	return nil
+	return company.toDTO(), nil
+}
+
+// PurgeCompanies permanently removes up to limit companies soft-deleted more than olderThan ago
+// and returns them. Rows locked by concurrent purges are skipped.
+func (r *companyRepository) PurgeCompanies(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	olderThan time.Duration,
+	limit int,
+) ([]model.Company, error) {
+	query := `
+		DELETE FROM companies
+		WHERE id IN (
+			SELECT id FROM companies
+			WHERE deleted_at < NOW() - make_interval(secs => $1)
+			ORDER BY deleted_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + companyColumns
+
+	var rows []Company
+	if err := tx.SelectContext(ctx, &rows, query, olderThan.Seconds(), limit); err != nil {
+		return nil, apperrors.NewInternalServerError("failed to purge companies").WithCause(err)
+	}
+
+	companies := make([]model.Company, 0, len(rows))
+	for _, row := range rows {
+		companies = append(companies, row.toDTO())
+	}
+	return companies, nil
 }
 
+// CountCompaniesByType counts the companies that aren't deleted by type.
+func (r *companyRepository) CountCompaniesByType(ctx context.Context, tx *sqlx.Tx) (map[model.CompanyType]int, error) {
+	query := `
+		SELECT type, COUNT(*) AS count
+		FROM companies
+		WHERE deleted_at IS NULL
+		GROUP BY type`
+
+	var rows []struct {
+		Type  model.CompanyType `db:"type"`
+		Count int               `db:"count"`
+	}
+	if err := tx.SelectContext(ctx, &rows, query); err != nil {
+		return nil, apperrors.NewInternalServerError("failed to count companies").WithCause(err)
+	}
+
+	counts := make(map[model.CompanyType]int, len(rows))
+	for _, row := range rows {
+		counts[row.Type] = row.Count
+	}
+	return counts, nil
+}
+
+// UpdateCompany applies the given changes, bumps the company version and returns the updated company.
 func (r *companyRepository) UpdateCompany(
 	ctx context.Context,
 	tx *sqlx.Tx,
 	updates model.UpdateCompanyData,
-) error {
+) (model.Company, error) {
 	var query string
 	var args []any
 
 	var setClauses []string
+	if updates.ID != nil && updates.Name != nil {
+		// the company is looked up by id, so the name is a new value
+		setClauses = append(setClauses, "name = ?")
+		args = append(args, *updates.Name)
+	}
 	if updates.Description != nil {
 		setClauses = append(setClauses, "description = ?")
 		args = append(args, *updates.Description)
@@ -203,34 +490,62 @@ func (r *companyRepository) UpdateCompany(
 	}
 
 	if len(setClauses) == 0 {
-		return apperrors.NewBadRequestError("no fields to update")
+		return model.Company{}, apperrors.NewBadRequestError("no fields to update")
 	}
 
-	setClauses = append(setClauses, "updated_at = NOW()")
+	setClauses = append(setClauses, "version = version + 1", "updated_at = NOW()")
 
 	switch {
 	case updates.ID != nil:
-		query = fmt.Sprintf("UPDATE companies SET %s WHERE id = ?", strings.Join(setClauses, ", "))
+		query = fmt.Sprintf("UPDATE companies SET %s WHERE id = ? AND deleted_at IS NULL RETURNING %s",
+			strings.Join(setClauses, ", "), companyColumns)
 		args = append(args, *updates.ID)
 	case updates.Name != nil:
-		query = fmt.Sprintf("UPDATE companies SET %s WHERE name = ?", strings.Join(setClauses, ", "))
+		query = fmt.Sprintf("UPDATE companies SET %s WHERE name = ? AND deleted_at IS NULL RETURNING %s",
+			strings.Join(setClauses, ", "), companyColumns)
 		args = append(args, *updates.Name)
 	default:
-		return apperrors.NewBadRequestError("either uuid or name must be provided")
+		return model.Company{}, apperrors.NewBadRequestError("either uuid or name must be provided")
 	}
 
 	query = sqlx.Rebind(sqlx.DOLLAR, query)
-	result, err := tx.ExecContext(ctx, query, args...)
+
+	var company Company
+	err := tx.GetContext(ctx, &company, query, args...)
 	if err != nil {
-		return apperrors.NewInternalServerError("failed to update company").WithCause(err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.Company{}, apperrors.NewNotFoundError("company not found")
+		}
+		return model.Company{}, mapUniqueViolation(err, "failed to update company")
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	return company.toDTO(), nil
+}
+
+// AddNameAlias keeps the previous name of a renamed company resolvable. An alias matching
+// the new name of the company is dropped, as the name is live again.
+func (r *companyRepository) AddNameAlias(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	companyID uuid.UUID,
+	oldName string,
+	newName string,
+) error {
+	_, err := tx.ExecContext(ctx, `
+		DELETE FROM company_name_aliases
+		WHERE name = $1 AND company_id = $2
+	`, newName, companyID)
 	if err != nil {
-		return apperrors.NewInternalServerError("failed to get rows affected").WithCause(err)
+		return apperrors.NewInternalServerError("failed to delete company name alias").WithCause(err)
 	}
-	if rowsAffected == 0 {
-		return apperrors.NewNotFoundError("company not found")
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO company_name_aliases (name, company_id)
+		VALUES ($1, $2)
+		ON CONFLICT (name) DO UPDATE SET company_id = EXCLUDED.company_id, created_at = CURRENT_TIMESTAMP
+	`, oldName, companyID)
+	if err != nil {
+		return apperrors.NewInternalServerError("failed to create company name alias").WithCause(err)
 	}
 
 	return nil
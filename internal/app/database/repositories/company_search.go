@@ -0,0 +1,104 @@
+package repositories
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/faeelol/companies-store/internal/app/apperrors"
+	"github.com/faeelol/companies-store/internal/app/model"
+)
+
+const headlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=30, MinWords=10"
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+type searchCursor struct {
+	Query string    `json:"q"`
+	Rank  string    `json:"r"`
+	ID    uuid.UUID `json:"id"`
+}
+
+type companySearchRow struct {
+	Company
+	Rank                 float64 `db:"rank"`
+	NameHighlight        string  `db:"name_highlight"`
+	DescriptionHighlight string  `db:"description_highlight"`
+}
+
+// SearchCompanies performs a full-text search over company names and descriptions.
+// Full-text matches are combined with trigram similarity and substring matches on the
+// name, so partial names are found too. Results are ordered by rank.
+func (r *companyRepository) SearchCompanies(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	params model.SearchCompaniesParams,
+) (model.CompanySearchPage, error) {
+	var cursorRank, cursorID any
+	if params.Cursor != "" {
+		var cursor searchCursor
+		if err := decodeCursor(params.Cursor, &cursor); err != nil || cursor.Query != params.Query {
+			return model.CompanySearchPage{}, apperrors.NewBadRequestError("invalid cursor")
+		}
+		cursorRank, cursorID = cursor.Rank, cursor.ID
+	}
+
+	query := `
+		SELECT s.id, s.name, s.description, s.employees_count, s.registered, s.type, s.version, s.created_at, s.updated_at, s.deleted_at,
+			s.rank,
+			ts_headline('english', s.name, s.query, $3) AS name_highlight,
+			ts_headline('english', coalesce(s.description, ''), s.query, $3) AS description_highlight
+		FROM (
+			SELECT c.*, q.query,
+				(ts_rank(c.search_vector, q.query) + similarity(c.name, $1))::float8 AS rank
+			FROM companies c, websearch_to_tsquery('english', $1) AS q(query)
+			WHERE c.deleted_at IS NULL
+				AND (c.search_vector @@ q.query OR c.name ILIKE $2 OR c.name % $1)
+		) s
+		WHERE $4::float8 IS NULL OR (s.rank, s.id) < ($4::float8, $5::uuid)
+		ORDER BY s.rank DESC, s.id DESC
+		LIMIT $6
+	`
+	likePattern := "%" + likeEscaper.Replace(params.Query) + "%"
+	// one extra row tells whether there is a next page
+	args := []any{params.Query, likePattern, headlineOptions, cursorRank, cursorID, params.Limit + 1}
+
+	var rows []companySearchRow
+	if err := tx.SelectContext(ctx, &rows, query, args...); err != nil {
+		return model.CompanySearchPage{}, apperrors.NewInternalServerError("failed to search companies").WithCause(err)
+	}
+
+	page := model.CompanySearchPage{
+		Results: make([]model.CompanySearchHit, 0, len(rows)),
+	}
+
+	if len(rows) > params.Limit {
+		rows = rows[:params.Limit]
+		last := rows[len(rows)-1]
+		next, err := encodeCursor(searchCursor{
+			Query: params.Query,
+			Rank:  strconv.FormatFloat(last.Rank, 'g', -1, 64),
+			ID:    last.ID,
+		})
+		if err != nil {
+			return model.CompanySearchPage{}, apperrors.NewInternalServerError("failed to encode cursor").WithCause(err)
+		}
+		page.NextCursor = next
+	}
+
+	for _, row := range rows {
+		page.Results = append(page.Results, model.CompanySearchHit{
+			Company: row.toDTO(),
+			Rank:    row.Rank,
+			Highlights: model.CompanyHighlights{
+				Name:        row.NameHighlight,
+				Description: row.DescriptionHighlight,
+			},
+		})
+	}
+
+	return page, nil
+}
@@ -0,0 +1,138 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/faeelol/companies-store/internal/app/apperrors"
+)
+
+// expiredKeysCleanupBatch bounds how many expired keys are removed along with each reservation.
+const expiredKeysCleanupBatch = 10
+
+type IdempotencyRepository interface {
+	ReserveKey(ctx context.Context, tx *sqlx.Tx, scope, key, requestHash string, ttl, lockTimeout time.Duration) (bool, error)
+	RefreshKeyLock(ctx context.Context, tx *sqlx.Tx, scope, key string) error
+	GetKey(ctx context.Context, tx *sqlx.Tx, scope, key string) (IdempotencyKey, error)
+	CompleteKey(ctx context.Context, tx *sqlx.Tx, record IdempotencyKey) error
+	DeleteKey(ctx context.Context, tx *sqlx.Tx, scope, key string) error
+}
+
+type idempotencyRepository struct {
+}
+
+// IdempotencyKey is a request processed under an Idempotency-Key. StatusCode is not set
+// while the request is still in progress, and LockedAt is when the request last said it was.
+type IdempotencyKey struct {
+	Scope           string        `db:"scope"`
+	Key             string        `db:"key"`
+	RequestHash     string        `db:"request_hash"`
+	StatusCode      sql.NullInt32 `db:"status_code"`
+	ResponseHeaders []byte        `db:"response_headers"`
+	ResponseBody    []byte        `db:"response_body"`
+	CreatedAt       time.Time     `db:"created_at"`
+	ExpiresAt       time.Time     `db:"expires_at"`
+	LockedAt        *time.Time    `db:"locked_at"`
+}
+
+func NewIdempotencyRepository() IdempotencyRepository {
+	return &idempotencyRepository{}
+}
+
+// ReserveKey claims the key for a new request. It returns false when the key is already taken
+// by a request that hasn't expired yet. A key left in progress by a request that stopped refreshing
+// its lock for lockTimeout, because its process died, is taken over by a repeat of the same request.
+func (r *idempotencyRepository) ReserveKey(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	scope, key, requestHash string,
+	ttl, lockTimeout time.Duration,
+) (bool, error) {
+	_, err := tx.ExecContext(ctx, `
+		DELETE FROM idempotency_keys
+		WHERE (scope, key) IN (
+			SELECT scope, key FROM idempotency_keys
+			WHERE expires_at < NOW()
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		) OR (scope = $2 AND key = $3 AND expires_at < NOW())
+	`, expiredKeysCleanupBatch, scope, key)
+	if err != nil {
+		return false, apperrors.NewInternalServerError("failed to delete expired idempotency keys").WithCause(err)
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO idempotency_keys (scope, key, request_hash, expires_at, locked_at)
+		VALUES ($1, $2, $3, NOW() + make_interval(secs => $4), NOW())
+		ON CONFLICT (scope, key) DO UPDATE
+		SET created_at = NOW(), expires_at = EXCLUDED.expires_at, locked_at = NOW()
+		WHERE idempotency_keys.status_code IS NULL
+			AND idempotency_keys.request_hash = EXCLUDED.request_hash
+			AND idempotency_keys.locked_at < NOW() - make_interval(secs => $5)
+	`, scope, key, requestHash, ttl.Seconds(), lockTimeout.Seconds())
+	if err != nil {
+		return false, apperrors.NewInternalServerError("failed to reserve idempotency key").WithCause(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, apperrors.NewInternalServerError("failed to get rows affected").WithCause(err)
+	}
+
+	return rowsAffected == 1, nil
+}
+
+// GetKey retrieves a stored idempotency key.
+func (r *idempotencyRepository) GetKey(ctx context.Context, tx *sqlx.Tx, scope, key string) (IdempotencyKey, error) {
+	query := `
+		SELECT scope, key, request_hash, status_code, response_headers, response_body, created_at, expires_at, locked_at
+		FROM idempotency_keys
+		WHERE scope = $1 AND key = $2
+	`
+
+	var record IdempotencyKey
+	if err := tx.GetContext(ctx, &record, query, scope, key); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return IdempotencyKey{}, apperrors.NewNotFoundError("idempotency key not found")
+		}
+		return IdempotencyKey{}, apperrors.NewInternalServerError("failed to query idempotency key").WithCause(err)
+	}
+
+	return record, nil
+}
+
+// RefreshKeyLock tells the key is still held by a request in progress.
+func (r *idempotencyRepository) RefreshKeyLock(ctx context.Context, tx *sqlx.Tx, scope, key string) error {
+	query := `UPDATE idempotency_keys SET locked_at = NOW() WHERE scope = $1 AND key = $2 AND status_code IS NULL`
+	if _, err := tx.ExecContext(ctx, query, scope, key); err != nil {
+		return apperrors.NewInternalServerError("failed to refresh idempotency key lock").WithCause(err)
+	}
+	return nil
+}
+
+// CompleteKey stores the response of the request made under the key.
+func (r *idempotencyRepository) CompleteKey(ctx context.Context, tx *sqlx.Tx, record IdempotencyKey) error {
+	query := `
+		UPDATE idempotency_keys
+		SET status_code = :status_code, response_headers = :response_headers, response_body = :response_body,
+			locked_at = NULL
+		WHERE scope = :scope AND key = :key
+	`
+
+	if _, err := tx.NamedExecContext(ctx, query, record); err != nil {
+		return apperrors.NewInternalServerError("failed to store idempotent response").WithCause(err)
+	}
+	return nil
+}
+
+// DeleteKey releases the key, so the request can be retried with it.
+func (r *idempotencyRepository) DeleteKey(ctx context.Context, tx *sqlx.Tx, scope, key string) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE scope = $1 AND key = $2", scope, key); err != nil {
+		return apperrors.NewInternalServerError("failed to delete idempotency key").WithCause(err)
+	}
+	return nil
+}
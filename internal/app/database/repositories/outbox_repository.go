@@ -0,0 +1,204 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"github.com/faeelol/companies-store/internal/app/apperrors"
+)
+
+// outboxRelayLockID identifies the advisory lock held by the relay publishing the outbox.
+const outboxRelayLockID = 7_361_290_113
+
+type OutboxRepository interface {
+	AddMessage(ctx context.Context, tx *sqlx.Tx, message *OutboxMessage) error
+	TryLockRelay(ctx context.Context, conn *sqlx.Conn) (bool, error)
+	UnlockRelay(ctx context.Context, conn *sqlx.Conn) error
+	ListPending(ctx context.Context, tx *sqlx.Tx, limit int) ([]OutboxMessage, error)
+	MarkSent(ctx context.Context, tx *sqlx.Tx, ids []int64) error
+	MarkFailed(ctx context.Context, tx *sqlx.Tx, id int64, nextAttemptAt time.Time, lastError string) error
+	DeleteSent(ctx context.Context, tx *sqlx.Tx, olderThan time.Duration, limit int) (int64, error)
+	GetLag(ctx context.Context, tx *sqlx.Tx) (OutboxLag, error)
+}
+
+type outboxRepository struct {
+}
+
+// OutboxMessage is an event waiting in the outbox to be published to Kafka. A nil payload
+// is published as a tombstone.
+type OutboxMessage struct {
+	ID            int64          `db:"id"`
+	Key           string         `db:"key"`
+	Payload       []byte         `db:"payload"`
+	Headers       OutboxHeaders  `db:"headers"`
+	Attempts      int            `db:"attempts"`
+	LastError     sql.NullString `db:"last_error"`
+	NextAttemptAt time.Time      `db:"next_attempt_at"`
+	CreatedAt     time.Time      `db:"created_at"`
+	SentAt        *time.Time     `db:"sent_at"`
+}
+
+// OutboxHeaders are the Kafka headers of an outbox message, stored as a JSON object.
+type OutboxHeaders map[string]string
+
+func (h OutboxHeaders) Value() (driver.Value, error) {
+	if h == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(h)
+}
+
+func (h *OutboxHeaders) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*h = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, h)
+	case string:
+		return json.Unmarshal([]byte(v), h)
+	default:
+		return fmt.Errorf("unsupported outbox headers type %T", src)
+	}
+}
+
+// OutboxLag describes the messages not published yet.
+type OutboxLag struct {
+	Pending       int        `db:"pending"`
+	OldestCreated *time.Time `db:"oldest_created_at"`
+}
+
+func NewOutboxRepository() OutboxRepository {
+	return &outboxRepository{}
+}
+
+// AddMessage stores a message in the outbox. It's meant to be called in the transaction
+// changing the data the message is about.
+func (r *outboxRepository) AddMessage(ctx context.Context, tx *sqlx.Tx, message *OutboxMessage) error {
+	query := `
+		INSERT INTO outbox (key, payload, headers)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at, next_attempt_at
+	`
+
+	// a nil slice has to be passed as an untyped nil to be stored as NULL
+	var payload any
+	if message.Payload != nil {
+		payload = message.Payload
+	}
+
+	row := tx.QueryRowxContext(ctx, query, message.Key, payload, message.Headers)
+	if err := row.StructScan(message); err != nil {
+		return apperrors.NewInternalServerError("failed to add outbox message").WithCause(err)
+	}
+	return nil
+}
+
+// TryLockRelay takes the relay lock for the session of the connection, until UnlockRelay or the
+// connection is closed. It returns false when another relay holds it.
+func (r *outboxRepository) TryLockRelay(ctx context.Context, conn *sqlx.Conn) (bool, error) {
+	var locked bool
+	if err := conn.GetContext(ctx, &locked, `SELECT pg_try_advisory_lock($1)`, outboxRelayLockID); err != nil {
+		return false, apperrors.NewInternalServerError("failed to lock outbox relay").WithCause(err)
+	}
+	return locked, nil
+}
+
+// UnlockRelay releases the relay lock taken by TryLockRelay on the connection.
+func (r *outboxRepository) UnlockRelay(ctx context.Context, conn *sqlx.Conn) error {
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, outboxRelayLockID); err != nil {
+		return apperrors.NewInternalServerError("failed to unlock outbox relay").WithCause(err)
+	}
+	return nil
+}
+
+// ListPending returns the oldest messages not published yet, in the order they were added.
+func (r *outboxRepository) ListPending(ctx context.Context, tx *sqlx.Tx, limit int) ([]OutboxMessage, error) {
+	query := `
+		SELECT id, key, payload, headers, attempts, last_error, next_attempt_at, created_at, sent_at
+		FROM outbox
+		WHERE sent_at IS NULL
+		ORDER BY id
+		LIMIT $1
+	`
+
+	var messages []OutboxMessage
+	if err := tx.SelectContext(ctx, &messages, query, limit); err != nil {
+		return nil, apperrors.NewInternalServerError("failed to list pending outbox messages").WithCause(err)
+	}
+	return messages, nil
+}
+
+func (r *outboxRepository) MarkSent(ctx context.Context, tx *sqlx.Tx, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	query := `UPDATE outbox SET sent_at = NOW(), last_error = NULL WHERE id = ANY($1)`
+	if _, err := tx.ExecContext(ctx, query, pq.Array(ids)); err != nil {
+		return apperrors.NewInternalServerError("failed to mark outbox messages as sent").WithCause(err)
+	}
+	return nil
+}
+
+// MarkFailed records a failed publish attempt and postpones the next one.
+func (r *outboxRepository) MarkFailed(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	id int64,
+	nextAttemptAt time.Time,
+	lastError string,
+) error {
+	query := `
+		UPDATE outbox
+		SET attempts = attempts + 1, next_attempt_at = $2, last_error = $3
+		WHERE id = $1
+	`
+	if _, err := tx.ExecContext(ctx, query, id, nextAttemptAt, lastError); err != nil {
+		return apperrors.NewInternalServerError("failed to mark outbox message as failed").WithCause(err)
+	}
+	return nil
+}
+
+// DeleteSent removes up to limit messages published more than olderThan ago.
+func (r *outboxRepository) DeleteSent(ctx context.Context, tx *sqlx.Tx, olderThan time.Duration, limit int) (int64, error) {
+	query := `
+		DELETE FROM outbox
+		WHERE id IN (
+			SELECT id FROM outbox
+			WHERE sent_at < NOW() - make_interval(secs => $1)
+			LIMIT $2
+		)
+	`
+
+	res, err := tx.ExecContext(ctx, query, olderThan.Seconds(), limit)
+	if err != nil {
+		return 0, apperrors.NewInternalServerError("failed to delete sent outbox messages").WithCause(err)
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, apperrors.NewInternalServerError("failed to delete sent outbox messages").WithCause(err)
+	}
+	return deleted, nil
+}
+
+func (r *outboxRepository) GetLag(ctx context.Context, tx *sqlx.Tx) (OutboxLag, error) {
+	query := `
+		SELECT COUNT(*) AS pending, MIN(created_at) AS oldest_created_at
+		FROM outbox
+		WHERE sent_at IS NULL
+	`
+
+	var lag OutboxLag
+	if err := tx.GetContext(ctx, &lag, query); err != nil {
+		return OutboxLag{}, apperrors.NewInternalServerError("failed to get outbox lag").WithCause(err)
+	}
+	return lag, nil
+}
@@ -0,0 +1,180 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/faeelol/companies-store/internal/app/apperrors"
+	"github.com/faeelol/companies-store/internal/app/model"
+)
+
+type RevisionRepository interface {
+	CreateRevision(ctx context.Context, tx *sqlx.Tx, revision *Revision) error
+	ListRevisions(ctx context.Context, tx *sqlx.Tx, params model.ListRevisionsParams) (model.CompanyRevisionsPage, error)
+	GetCompanyAsOf(ctx context.Context, tx *sqlx.Tx, companyID uuid.UUID, asOf time.Time) (model.Company, error)
+}
+
+type revisionRepository struct {
+}
+
+type Revision struct {
+	ID        int64          `db:"id"`
+	CompanyID uuid.UUID      `db:"company_id"`
+	Operation string         `db:"operation"`
+	Version   int            `db:"version"`
+	Snapshot  []byte         `db:"snapshot"`
+	Actor     sql.NullString `db:"actor"`
+	RequestID sql.NullString `db:"request_id"`
+	CreatedAt time.Time      `db:"created_at"`
+}
+
+// NewRevision builds a revision holding the snapshot of the company.
+func NewRevision(operation model.RevisionOperation, company model.Company, actor, requestID string) (*Revision, error) {
+	snapshot, err := json.Marshal(company)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Revision{
+		CompanyID: company.ID,
+		Operation: string(operation),
+		Version:   company.Version,
+		Snapshot:  snapshot,
+		Actor:     sql.NullString{String: actor, Valid: actor != ""},
+		RequestID: sql.NullString{String: requestID, Valid: requestID != ""},
+	}, nil
+}
+
+func (rev Revision) toDTO() (model.CompanyRevision, error) {
+	var snapshot model.Company
+	if err := json.Unmarshal(rev.Snapshot, &snapshot); err != nil {
+		return model.CompanyRevision{}, err
+	}
+
+	return model.CompanyRevision{
+		ID:        rev.ID,
+		CompanyID: rev.CompanyID,
+		Operation: model.RevisionOperation(rev.Operation),
+		Version:   rev.Version,
+		Snapshot:  snapshot,
+		Actor:     rev.Actor.String,
+		RequestID: rev.RequestID.String,
+		CreatedAt: rev.CreatedAt,
+	}, nil
+}
+
+type revisionsCursor struct {
+	ID int64 `json:"id"`
+}
+
+func NewRevisionRepository() RevisionRepository {
+	return &revisionRepository{}
+}
+
+// CreateRevision stores a company revision.
+func (r *revisionRepository) CreateRevision(ctx context.Context, tx *sqlx.Tx, revision *Revision) error {
+	query := `
+		INSERT INTO company_revisions (company_id, operation, version, snapshot, actor, request_id)
+		VALUES (:company_id, :operation, :version, :snapshot, :actor, :request_id)
+	`
+
+	if _, err := tx.NamedExecContext(ctx, query, revision); err != nil {
+		return apperrors.NewInternalServerError("failed to create company revision").WithCause(err)
+	}
+	return nil
+}
+
+// ListRevisions returns revisions of a company, newest first.
+func (r *revisionRepository) ListRevisions(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	params model.ListRevisionsParams,
+) (model.CompanyRevisionsPage, error) {
+	var beforeID any
+	if params.Cursor != "" {
+		var cursor revisionsCursor
+		if err := decodeCursor(params.Cursor, &cursor); err != nil {
+			return model.CompanyRevisionsPage{}, apperrors.NewBadRequestError("invalid cursor")
+		}
+		beforeID = cursor.ID
+	}
+
+	query := `
+		SELECT id, company_id, operation, version, snapshot, actor, request_id, created_at
+		FROM company_revisions
+		WHERE company_id = $1 AND ($2::bigint IS NULL OR id < $2::bigint)
+		ORDER BY id DESC
+		LIMIT $3
+	`
+
+	var rows []Revision
+	// one extra row tells whether there is a next page
+	if err := tx.SelectContext(ctx, &rows, query, params.CompanyID, beforeID, params.Limit+1); err != nil {
+		return model.CompanyRevisionsPage{}, apperrors.NewInternalServerError("failed to list company revisions").WithCause(err)
+	}
+
+	page := model.CompanyRevisionsPage{
+		Revisions: make([]model.CompanyRevision, 0, len(rows)),
+	}
+
+	if len(rows) > params.Limit {
+		rows = rows[:params.Limit]
+		next, err := encodeCursor(revisionsCursor{ID: rows[len(rows)-1].ID})
+		if err != nil {
+			return model.CompanyRevisionsPage{}, apperrors.NewInternalServerError("failed to encode cursor").WithCause(err)
+		}
+		page.NextCursor = next
+	}
+
+	for _, row := range rows {
+		revision, err := row.toDTO()
+		if err != nil {
+			return model.CompanyRevisionsPage{}, apperrors.NewInternalServerError("failed to decode company revision").WithCause(err)
+		}
+		page.Revisions = append(page.Revisions, revision)
+	}
+
+	return page, nil
+}
+
+// GetCompanyAsOf returns the company as it was at the given moment, based on its revisions.
+func (r *revisionRepository) GetCompanyAsOf(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	companyID uuid.UUID,
+	asOf time.Time,
+) (model.Company, error) {
+	query := `
+		SELECT id, company_id, operation, version, snapshot, actor, request_id, created_at
+		FROM company_revisions
+		WHERE company_id = $1 AND created_at <= $2
+		ORDER BY created_at DESC, id DESC
+		LIMIT 1
+	`
+
+	var row Revision
+	if err := tx.GetContext(ctx, &row, query, companyID, asOf); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.Company{}, apperrors.NewNotFoundError("company not found")
+		}
+		return model.Company{}, apperrors.NewInternalServerError("failed to query company revision").WithCause(err)
+	}
+
+	switch model.RevisionOperation(row.Operation) {
+	case model.RevisionDelete, model.RevisionPurge:
+		return model.Company{}, apperrors.NewNotFoundError("company not found")
+	}
+
+	revision, err := row.toDTO()
+	if err != nil {
+		return model.Company{}, apperrors.NewInternalServerError("failed to decode company revision").WithCause(err)
+	}
+
+	return revision.Snapshot, nil
+}
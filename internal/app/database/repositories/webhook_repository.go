@@ -0,0 +1,483 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"github.com/faeelol/companies-store/internal/app/apperrors"
+	"github.com/faeelol/companies-store/internal/app/model"
+)
+
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, tx *sqlx.Tx, subscription *WebhookSubscription) (model.WebhookSubscription, error)
+	GetSubscription(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (model.WebhookSubscription, error)
+	GetSubscriptionForUpdate(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context, tx *sqlx.Tx, params model.ListWebhooksParams) (model.WebhookSubscriptionsPage, error)
+	UpdateSubscription(ctx context.Context, tx *sqlx.Tx, subscription *WebhookSubscription) (model.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) error
+	RecordSubscriptionSuccess(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) error
+	RecordSubscriptionFailure(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, disableAfter int) (bool, error)
+
+	AddDeliveries(ctx context.Context, tx *sqlx.Tx, eventID, eventType string, payload []byte) error
+	ClaimDueDeliveries(ctx context.Context, tx *sqlx.Tx, limit int, claimFor time.Duration) ([]DueWebhookDelivery, error)
+	MarkDeliverySucceeded(ctx context.Context, tx *sqlx.Tx, id int64, responseStatus int) error
+	MarkDeliveryFailed(ctx context.Context, tx *sqlx.Tx, id int64, attempt FailedWebhookAttempt) error
+	ListDeliveries(ctx context.Context, tx *sqlx.Tx, params model.ListWebhookDeliveriesParams) (model.WebhookDeliveriesPage, error)
+	DeleteFinishedDeliveries(ctx context.Context, tx *sqlx.Tx, olderThan time.Duration, limit int) (int64, error)
+}
+
+type webhookRepository struct {
+}
+
+const webhookSubscriptionColumns = "id, url, event_types, secret, description, enabled, disabled_reason, " +
+	"consecutive_failures, created_at, updated_at"
+
+const webhookDeliveryColumns = "id, subscription_id, event_id, event_type, status, attempts, response_status, " +
+	"last_error, next_attempt_at, last_attempt_at, created_at, finished_at"
+
+type WebhookSubscription struct {
+	ID                  uuid.UUID      `db:"id"`
+	URL                 string         `db:"url"`
+	EventTypes          pq.StringArray `db:"event_types"`
+	Secret              string         `db:"secret"`
+	Description         string         `db:"description"`
+	Enabled             bool           `db:"enabled"`
+	DisabledReason      sql.NullString `db:"disabled_reason"`
+	ConsecutiveFailures int            `db:"consecutive_failures"`
+	CreatedAt           time.Time      `db:"created_at"`
+	UpdatedAt           time.Time      `db:"updated_at"`
+}
+
+// toDTO converts the subscription, leaving the secret out.
+func (s WebhookSubscription) toDTO() model.WebhookSubscription {
+	eventTypes := []string(s.EventTypes)
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+	return model.WebhookSubscription{
+		ID:                  s.ID,
+		URL:                 s.URL,
+		EventTypes:          eventTypes,
+		Description:         s.Description,
+		Enabled:             s.Enabled,
+		DisabledReason:      s.DisabledReason.String,
+		ConsecutiveFailures: s.ConsecutiveFailures,
+		CreatedAt:           s.CreatedAt,
+		UpdatedAt:           s.UpdatedAt,
+	}
+}
+
+type WebhookDelivery struct {
+	ID             int64          `db:"id"`
+	SubscriptionID uuid.UUID      `db:"subscription_id"`
+	EventID        string         `db:"event_id"`
+	EventType      string         `db:"event_type"`
+	Status         string         `db:"status"`
+	Attempts       int            `db:"attempts"`
+	ResponseStatus sql.NullInt32  `db:"response_status"`
+	LastError      sql.NullString `db:"last_error"`
+	NextAttemptAt  time.Time      `db:"next_attempt_at"`
+	LastAttemptAt  *time.Time     `db:"last_attempt_at"`
+	CreatedAt      time.Time      `db:"created_at"`
+	FinishedAt     *time.Time     `db:"finished_at"`
+}
+
+func (d WebhookDelivery) toDTO() model.WebhookDelivery {
+	delivery := model.WebhookDelivery{
+		ID:             d.ID,
+		SubscriptionID: d.SubscriptionID,
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		Status:         model.WebhookDeliveryStatus(d.Status),
+		Attempts:       d.Attempts,
+		LastError:      d.LastError.String,
+		LastAttemptAt:  d.LastAttemptAt,
+		CreatedAt:      d.CreatedAt,
+		FinishedAt:     d.FinishedAt,
+	}
+	if d.ResponseStatus.Valid {
+		responseStatus := int(d.ResponseStatus.Int32)
+		delivery.ResponseStatus = &responseStatus
+	}
+	if delivery.Status == model.WebhookDeliveryPending {
+		nextAttemptAt := d.NextAttemptAt
+		delivery.NextAttemptAt = &nextAttemptAt
+	}
+	return delivery
+}
+
+// DueWebhookDelivery is a delivery to attempt, with the endpoint of its subscription.
+type DueWebhookDelivery struct {
+	ID             int64     `db:"id"`
+	SubscriptionID uuid.UUID `db:"subscription_id"`
+	EventID        string    `db:"event_id"`
+	EventType      string    `db:"event_type"`
+	Payload        []byte    `db:"payload"`
+	Attempts       int       `db:"attempts"`
+	URL            string    `db:"url"`
+	Secret         string    `db:"secret"`
+}
+
+// FailedWebhookAttempt is the outcome of a failed delivery attempt. A nil NextAttemptAt
+// gives up the delivery.
+type FailedWebhookAttempt struct {
+	ResponseStatus *int
+	Error          string
+	NextAttemptAt  *time.Time
+}
+
+type webhooksCursor struct {
+	ID uuid.UUID `json:"id"`
+}
+
+type webhookDeliveriesCursor struct {
+	ID int64 `json:"id"`
+}
+
+func NewWebhookRepository() WebhookRepository {
+	return &webhookRepository{}
+}
+
+func (r *webhookRepository) CreateSubscription(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	subscription *WebhookSubscription,
+) (model.WebhookSubscription, error) {
+	query := `
+		INSERT INTO webhook_subscriptions (id, url, event_types, secret, description)
+		VALUES (:id, :url, :event_types, :secret, :description)
+		RETURNING ` + webhookSubscriptionColumns
+
+	var created WebhookSubscription
+	if _, err := namedGet(ctx, tx, &created, query, subscription); err != nil {
+		return model.WebhookSubscription{}, apperrors.NewInternalServerError("failed to create webhook subscription").WithCause(err)
+	}
+	return created.toDTO(), nil
+}
+
+func (r *webhookRepository) GetSubscription(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (model.WebhookSubscription, error) {
+	query := `SELECT ` + webhookSubscriptionColumns + ` FROM webhook_subscriptions WHERE id = $1`
+
+	var subscription WebhookSubscription
+	if err := tx.GetContext(ctx, &subscription, query, id); err != nil {
+		return model.WebhookSubscription{}, mapWebhookNotFound(err, "failed to query webhook subscription")
+	}
+	return subscription.toDTO(), nil
+}
+
+// GetSubscriptionForUpdate returns the subscription, secret included, locking it for the rest
+// of the transaction.
+func (r *webhookRepository) GetSubscriptionForUpdate(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) (WebhookSubscription, error) {
+	query := `SELECT ` + webhookSubscriptionColumns + ` FROM webhook_subscriptions WHERE id = $1 FOR UPDATE`
+
+	var subscription WebhookSubscription
+	if err := tx.GetContext(ctx, &subscription, query, id); err != nil {
+		return WebhookSubscription{}, mapWebhookNotFound(err, "failed to query webhook subscription")
+	}
+	return subscription, nil
+}
+
+// ListSubscriptions returns the subscriptions, oldest first.
+func (r *webhookRepository) ListSubscriptions(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	params model.ListWebhooksParams,
+) (model.WebhookSubscriptionsPage, error) {
+	var afterID any
+	if params.Cursor != "" {
+		var cursor webhooksCursor
+		if err := decodeCursor(params.Cursor, &cursor); err != nil {
+			return model.WebhookSubscriptionsPage{}, apperrors.NewBadRequestError("invalid cursor")
+		}
+		afterID = cursor.ID
+	}
+
+	// ids are UUIDv7, so they sort by creation time
+	query := `
+		SELECT ` + webhookSubscriptionColumns + `
+		FROM webhook_subscriptions
+		WHERE ($1::uuid IS NULL OR id > $1::uuid)
+		ORDER BY id
+		LIMIT $2
+	`
+
+	var rows []WebhookSubscription
+	// one extra row tells whether there is a next page
+	if err := tx.SelectContext(ctx, &rows, query, afterID, params.Limit+1); err != nil {
+		return model.WebhookSubscriptionsPage{}, apperrors.NewInternalServerError("failed to list webhook subscriptions").WithCause(err)
+	}
+
+	page := model.WebhookSubscriptionsPage{
+		Subscriptions: make([]model.WebhookSubscription, 0, len(rows)),
+	}
+
+	if len(rows) > params.Limit {
+		rows = rows[:params.Limit]
+		next, err := encodeCursor(webhooksCursor{ID: rows[len(rows)-1].ID})
+		if err != nil {
+			return model.WebhookSubscriptionsPage{}, apperrors.NewInternalServerError("failed to encode cursor").WithCause(err)
+		}
+		page.NextCursor = next
+	}
+
+	for _, row := range rows {
+		page.Subscriptions = append(page.Subscriptions, row.toDTO())
+	}
+
+	return page, nil
+}
+
+func (r *webhookRepository) UpdateSubscription(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	subscription *WebhookSubscription,
+) (model.WebhookSubscription, error) {
+	query := `
+		UPDATE webhook_subscriptions
+		SET url = :url, event_types = :event_types, secret = :secret, description = :description,
+			enabled = :enabled, disabled_reason = :disabled_reason, consecutive_failures = :consecutive_failures,
+			updated_at = NOW()
+		WHERE id = :id
+		RETURNING ` + webhookSubscriptionColumns
+
+	var updated WebhookSubscription
+	found, err := namedGet(ctx, tx, &updated, query, subscription)
+	if err != nil {
+		return model.WebhookSubscription{}, apperrors.NewInternalServerError("failed to update webhook subscription").WithCause(err)
+	}
+	if !found {
+		return model.WebhookSubscription{}, apperrors.NewNotFoundError("webhook subscription not found")
+	}
+	return updated.toDTO(), nil
+}
+
+// DeleteSubscription removes the subscription along with its deliveries.
+func (r *webhookRepository) DeleteSubscription(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) error {
+	res, err := tx.ExecContext(ctx, `DELETE FROM webhook_subscriptions WHERE id = $1`, id)
+	if err != nil {
+		return apperrors.NewInternalServerError("failed to delete webhook subscription").WithCause(err)
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return apperrors.NewInternalServerError("failed to delete webhook subscription").WithCause(err)
+	}
+	if deleted == 0 {
+		return apperrors.NewNotFoundError("webhook subscription not found")
+	}
+	return nil
+}
+
+// RecordSubscriptionSuccess resets the count of consecutive failed deliveries.
+func (r *webhookRepository) RecordSubscriptionSuccess(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) error {
+	query := `UPDATE webhook_subscriptions SET consecutive_failures = 0 WHERE id = $1 AND consecutive_failures > 0`
+	if _, err := tx.ExecContext(ctx, query, id); err != nil {
+		return apperrors.NewInternalServerError("failed to update webhook subscription").WithCause(err)
+	}
+	return nil
+}
+
+// RecordSubscriptionFailure counts a delivery that was given up, disabling the subscription once
+// disableAfter deliveries in a row were given up. It returns whether the subscription got disabled.
+func (r *webhookRepository) RecordSubscriptionFailure(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	id uuid.UUID,
+	disableAfter int,
+) (bool, error) {
+	query := `
+		UPDATE webhook_subscriptions
+		SET consecutive_failures = consecutive_failures + 1,
+			enabled = consecutive_failures + 1 < $2::int,
+			disabled_reason = CASE
+				WHEN consecutive_failures + 1 < $2::int THEN disabled_reason
+				ELSE 'disabled after ' || $2::int || ' failed deliveries in a row'
+			END,
+			updated_at = CASE WHEN consecutive_failures + 1 < $2::int THEN updated_at ELSE NOW() END
+		WHERE id = $1 AND enabled
+		RETURNING NOT enabled
+	`
+
+	var disabled bool
+	if err := tx.GetContext(ctx, &disabled, query, id, disableAfter); err != nil {
+		// the subscription was disabled or deleted meanwhile
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, apperrors.NewInternalServerError("failed to update webhook subscription").WithCause(err)
+	}
+	return disabled, nil
+}
+
+// AddDeliveries schedules the delivery of the event to every enabled subscription to its type.
+// It's meant to be called in the transaction changing the data the event is about.
+func (r *webhookRepository) AddDeliveries(ctx context.Context, tx *sqlx.Tx, eventID, eventType string, payload []byte) error {
+	query := `
+		INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload)
+		SELECT id, $1, $2, $3
+		FROM webhook_subscriptions
+		WHERE enabled AND (cardinality(event_types) = 0 OR $2 = ANY(event_types))
+	`
+
+	if _, err := tx.ExecContext(ctx, query, eventID, eventType, payload); err != nil {
+		return apperrors.NewInternalServerError("failed to add webhook deliveries").WithCause(err)
+	}
+	return nil
+}
+
+// ClaimDueDeliveries returns the pending deliveries of enabled subscriptions due to be attempted,
+// postponing their next attempt by claimFor so no other worker picks them up once the transaction
+// is committed. A delivery whose outcome isn't recorded in time is due again. Deliveries locked by
+// another worker are skipped.
+func (r *webhookRepository) ClaimDueDeliveries(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	limit int,
+	claimFor time.Duration,
+) ([]DueWebhookDelivery, error) {
+	query := `
+		WITH due AS (
+			SELECT d.id
+			FROM webhook_deliveries d
+			JOIN webhook_subscriptions s ON s.id = d.subscription_id
+			WHERE d.status = 'pending' AND d.next_attempt_at <= NOW() AND s.enabled
+			ORDER BY d.next_attempt_at, d.id
+			LIMIT $1
+			FOR UPDATE OF d SKIP LOCKED
+		)
+		UPDATE webhook_deliveries d
+		SET next_attempt_at = NOW() + make_interval(secs => $2)
+		FROM due, webhook_subscriptions s
+		WHERE d.id = due.id AND s.id = d.subscription_id
+		RETURNING d.id, d.subscription_id, d.event_id, d.event_type, d.payload, d.attempts, s.url, s.secret
+	`
+
+	var deliveries []DueWebhookDelivery
+	if err := tx.SelectContext(ctx, &deliveries, query, limit, claimFor.Seconds()); err != nil {
+		return nil, apperrors.NewInternalServerError("failed to claim due webhook deliveries").WithCause(err)
+	}
+	return deliveries, nil
+}
+
+func (r *webhookRepository) MarkDeliverySucceeded(ctx context.Context, tx *sqlx.Tx, id int64, responseStatus int) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = 'succeeded', attempts = attempts + 1, response_status = $2, last_error = NULL,
+			last_attempt_at = NOW(), finished_at = NOW()
+		WHERE id = $1
+	`
+	if _, err := tx.ExecContext(ctx, query, id, responseStatus); err != nil {
+		return apperrors.NewInternalServerError("failed to mark webhook delivery as succeeded").WithCause(err)
+	}
+	return nil
+}
+
+// MarkDeliveryFailed records a failed attempt, and either postpones the next one or gives up the delivery.
+func (r *webhookRepository) MarkDeliveryFailed(ctx context.Context, tx *sqlx.Tx, id int64, attempt FailedWebhookAttempt) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET attempts = attempts + 1, response_status = $2, last_error = $3, last_attempt_at = NOW(),
+			status = CASE WHEN $4::timestamptz IS NULL THEN 'failed' ELSE 'pending' END,
+			next_attempt_at = COALESCE($4::timestamptz, next_attempt_at),
+			finished_at = CASE WHEN $4::timestamptz IS NULL THEN NOW() END
+		WHERE id = $1
+	`
+	if _, err := tx.ExecContext(ctx, query, id, attempt.ResponseStatus, attempt.Error, attempt.NextAttemptAt); err != nil {
+		return apperrors.NewInternalServerError("failed to mark webhook delivery as failed").WithCause(err)
+	}
+	return nil
+}
+
+// ListDeliveries returns the deliveries of a subscription, newest first.
+func (r *webhookRepository) ListDeliveries(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	params model.ListWebhookDeliveriesParams,
+) (model.WebhookDeliveriesPage, error) {
+	var beforeID any
+	if params.Cursor != "" {
+		var cursor webhookDeliveriesCursor
+		if err := decodeCursor(params.Cursor, &cursor); err != nil {
+			return model.WebhookDeliveriesPage{}, apperrors.NewBadRequestError("invalid cursor")
+		}
+		beforeID = cursor.ID
+	}
+
+	var status any
+	if params.Status != nil {
+		status = string(*params.Status)
+	}
+
+	query := `
+		SELECT ` + webhookDeliveryColumns + `
+		FROM webhook_deliveries
+		WHERE subscription_id = $1 AND ($2::bigint IS NULL OR id < $2::bigint) AND ($3::text IS NULL OR status = $3::text)
+		ORDER BY id DESC
+		LIMIT $4
+	`
+
+	var rows []WebhookDelivery
+	// one extra row tells whether there is a next page
+	if err := tx.SelectContext(ctx, &rows, query, params.SubscriptionID, beforeID, status, params.Limit+1); err != nil {
+		return model.WebhookDeliveriesPage{}, apperrors.NewInternalServerError("failed to list webhook deliveries").WithCause(err)
+	}
+
+	page := model.WebhookDeliveriesPage{
+		Deliveries: make([]model.WebhookDelivery, 0, len(rows)),
+	}
+
+	if len(rows) > params.Limit {
+		rows = rows[:params.Limit]
+		next, err := encodeCursor(webhookDeliveriesCursor{ID: rows[len(rows)-1].ID})
+		if err != nil {
+			return model.WebhookDeliveriesPage{}, apperrors.NewInternalServerError("failed to encode cursor").WithCause(err)
+		}
+		page.NextCursor = next
+	}
+
+	for _, row := range rows {
+		page.Deliveries = append(page.Deliveries, row.toDTO())
+	}
+
+	return page, nil
+}
+
+// DeleteFinishedDeliveries removes up to limit deliveries that succeeded or were given up more
+// than olderThan ago.
+func (r *webhookRepository) DeleteFinishedDeliveries(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	olderThan time.Duration,
+	limit int,
+) (int64, error) {
+	query := `
+		DELETE FROM webhook_deliveries
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE finished_at < NOW() - make_interval(secs => $1)
+			LIMIT $2
+		)
+	`
+
+	res, err := tx.ExecContext(ctx, query, olderThan.Seconds(), limit)
+	if err != nil {
+		return 0, apperrors.NewInternalServerError("failed to delete finished webhook deliveries").WithCause(err)
+	}
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, apperrors.NewInternalServerError("failed to delete finished webhook deliveries").WithCause(err)
+	}
+	return deleted, nil
+}
+
+func mapWebhookNotFound(err error, message string) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return apperrors.NewNotFoundError("webhook subscription not found")
+	}
+	return apperrors.NewInternalServerError(message).WithCause(err)
+}
@@ -0,0 +1,37 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/spf13/viper"
+)
+
+type Config struct {
+	// Source identifies the service in the source attribute of the events.
+	Source string
+	// SchemaBaseURL is where the event schemas are published, it prefixes the dataschema attribute.
+	SchemaBaseURL string
+}
+
+func LoadEventsConfig() *Config {
+	viper.SetDefault("events.source", "/companies-store")
+	viper.SetDefault("events.schema_base_url", "http://localhost:8080/api/companies_repo/v1/events/schemas")
+
+	return &Config{
+		Source:        viper.GetString("events.source"),
+		SchemaBaseURL: viper.GetString("events.schema_base_url"),
+	}
+}
+
+func (c *Config) Validate() error {
+	var errs []error
+	if c.Source == "" {
+		errs = append(errs, errors.New("events.source is required"))
+	}
+	if u, err := url.Parse(c.SchemaBaseURL); err != nil || !u.IsAbs() {
+		errs = append(errs, fmt.Errorf("events.schema_base_url must be an absolute URL, got %q", c.SchemaBaseURL))
+	}
+	return errors.Join(errs...)
+}
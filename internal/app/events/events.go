@@ -0,0 +1,147 @@
+// Package events defines the CloudEvents published about companies and their JSON Schemas.
+package events
+
+import (
+	"embed"
+	"io/fs"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/faeelol/companies-store/internal/app/model"
+)
+
+const (
+	SpecVersion     = "1.0"
+	DataContentType = "application/json"
+
+	// ContentType is the content type of the events published in the structured mode of
+	// the CloudEvents Kafka binding.
+	ContentType = "application/cloudevents+json; charset=UTF-8"
+)
+
+// Kafka headers of the published messages.
+const (
+	HeaderContentType = "content-type"
+	HeaderType        = "ce_type"
+	HeaderRequestID   = "x-request-id"
+	HeaderActor       = "x-actor"
+)
+
+// Type is the CloudEvents type of an event. The version suffix changes with every
+// backward-incompatible change of the event data.
+type Type string
+
+const (
+	CompanyCreated  Type = "com.faeelol.companies.company.created.v1"
+	CompanyUpdated  Type = "com.faeelol.companies.company.updated.v1"
+	CompanyDeleted  Type = "com.faeelol.companies.company.deleted.v1"
+	CompanyRestored Type = "com.faeelol.companies.company.restored.v1"
+	CompanyPurged   Type = "com.faeelol.companies.company.purged.v1"
+	// CompanySnapshot carries the current state of a company, with a null before. It isn't about
+	// a change, snapshots are republished on demand to rebuild downstream state.
+	CompanySnapshot Type = "com.faeelol.companies.company.snapshot.v1"
+)
+
+const typePrefix = "com.faeelol.companies."
+
+// ChangeTypes lists the types of the events about company changes, the ones webhooks subscribe to.
+var ChangeTypes = []Type{CompanyCreated, CompanyUpdated, CompanyDeleted, CompanyRestored, CompanyPurged}
+
+// Types lists the types of the published events.
+var Types = slices.Concat(ChangeTypes, []Type{CompanySnapshot})
+
+// SchemaName is the file name of the JSON Schema of the event type, e.g. company.created.v1.json.
+func (t Type) SchemaName() string {
+	return strings.TrimPrefix(string(t), typePrefix) + ".json"
+}
+
+// Name is the event type without the prefix and the version, e.g. company.created.
+func (t Type) Name() string {
+	name := strings.TrimPrefix(string(t), typePrefix)
+	if i := strings.LastIndex(name, ".v"); i >= 0 {
+		return name[:i]
+	}
+	return name
+}
+
+//go:embed schemas/*.json
+var schemas embed.FS
+
+// Schema returns the JSON Schema with the given file name. The event schemas refer to the company
+// with a relative $ref to company.v1.json, which is served next to them.
+func Schema(name string) ([]byte, bool) {
+	schema, err := fs.ReadFile(schemas, "schemas/"+name)
+	if err != nil {
+		return nil, false
+	}
+	return schema, true
+}
+
+// SchemaNames lists the file names of the published JSON Schemas.
+func SchemaNames() []string {
+	names := make([]string, 0, len(Types))
+	for _, t := range Types {
+		names = append(names, t.SchemaName())
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CloudEvent is the CloudEvents 1.0 envelope of the events, in the JSON event format.
+type CloudEvent struct {
+	SpecVersion     string    `json:"specversion"`
+	ID              string    `json:"id"`
+	Source          string    `json:"source"`
+	Type            Type      `json:"type"`
+	Time            time.Time `json:"time"`
+	Subject         string    `json:"subject"`
+	DataContentType string    `json:"datacontenttype"`
+	DataSchema      string    `json:"dataschema"`
+	Data            any       `json:"data"`
+}
+
+// CompanyChange is the data of the company events: the full company before and after the change.
+// Before is null for a created company and after is null for a purged one.
+type CompanyChange struct {
+	Before *model.Company `json:"before"`
+	After  *model.Company `json:"after"`
+}
+
+type Builder struct {
+	cfg *Config
+}
+
+func NewBuilder(cfg *Config) *Builder {
+	return &Builder{cfg: cfg}
+}
+
+// CompanyEvent builds the event about a change of the company, the company id is the subject.
+func (b *Builder) CompanyEvent(eventType Type, before, after *model.Company) (CloudEvent, error) {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return CloudEvent{}, err
+	}
+
+	var companyID uuid.UUID
+	if after != nil {
+		companyID = after.ID
+	} else if before != nil {
+		companyID = before.ID
+	}
+
+	return CloudEvent{
+		SpecVersion:     SpecVersion,
+		ID:              id.String(),
+		Source:          b.cfg.Source,
+		Type:            eventType,
+		Time:            time.Now().UTC(),
+		Subject:         companyID.String(),
+		DataContentType: DataContentType,
+		DataSchema:      strings.TrimSuffix(b.cfg.SchemaBaseURL, "/") + "/" + eventType.SchemaName(),
+		Data:            CompanyChange{Before: before, After: after},
+	}, nil
+}
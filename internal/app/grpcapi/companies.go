@@ -0,0 +1,323 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/faeelol/companies-store/internal/app/apperrors"
+	"github.com/faeelol/companies-store/internal/app/logic/companies"
+	"github.com/faeelol/companies-store/internal/app/model"
+	companiesv1 "github.com/faeelol/companies-store/pkg/api/companies/v1"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+
+	maxNameLength        = 15
+	maxDescriptionLength = 3000
+)
+
+type CompaniesController interface {
+	CreateCompany(ctx context.Context, company model.CreateCompanyData) (model.Company, error)
+	GetCompany(ctx context.Context, reqUUID uuid.UUID, name string) (model.Company, error)
+	ListCompanies(ctx context.Context, params model.ListCompaniesParams) (model.CompaniesPage, error)
+	UpdateCompany(ctx context.Context, updates model.UpdateCompanyData, expectedVersions []int) (model.Company, error)
+	DeleteCompany(ctx context.Context, reqUUID uuid.UUID, name string, expectedVersions []int) error
+}
+
+var companyTypes = map[companiesv1.CompanyType]model.CompanyType{
+	companiesv1.CompanyType_COMPANY_TYPE_CORPORATIONS:        model.Corporations,
+	companiesv1.CompanyType_COMPANY_TYPE_NON_PROFIT:          model.NonProfit,
+	companiesv1.CompanyType_COMPANY_TYPE_COOPERATIVE:         model.Cooperative,
+	companiesv1.CompanyType_COMPANY_TYPE_SOLE_PROPRIETORSHIP: model.SoleProprietorship,
+}
+
+// CompaniesService implements the gRPC companies API on top of the companies controller.
+type CompaniesService struct {
+	companiesv1.UnimplementedCompaniesServiceServer
+
+	cc CompaniesController
+}
+
+func NewCompaniesService(cc CompaniesController) *CompaniesService {
+	return &CompaniesService{cc: cc}
+}
+
+func (s *CompaniesService) CreateCompany(
+	ctx context.Context,
+	req *companiesv1.CreateCompanyRequest,
+) (*companiesv1.CreateCompanyResponse, error) {
+	var v validator
+	id := v.optionalUUID("/id", req.GetId())
+	v.name("/name", req.GetName())
+	v.description("/description", req.GetDescription())
+	v.employeesCount("/employees_count", req.GetEmployeesCount())
+	companyType := v.companyType("/type", req.GetType())
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	created, err := s.cc.CreateCompany(ctx, model.CreateCompanyData{
+		ID:             id,
+		Name:           req.GetName(),
+		Description:    req.GetDescription(),
+		EmployeesCount: int(req.GetEmployeesCount()),
+		Registered:     req.GetRegistered(),
+		Type:           companyType,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &companiesv1.CreateCompanyResponse{Company: toProtoCompany(created)}, nil
+}
+
+func (s *CompaniesService) GetCompany(
+	ctx context.Context,
+	req *companiesv1.GetCompanyRequest,
+) (*companiesv1.GetCompanyResponse, error) {
+	var v validator
+	id := v.optionalUUID("/id", req.GetId())
+	if req.GetIdentifier() == nil {
+		v.add("/id", "id or name should be provided")
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	company, err := s.cc.GetCompany(ctx, id, req.GetName())
+	if err != nil {
+		return nil, err
+	}
+
+	return &companiesv1.GetCompanyResponse{Company: toProtoCompany(company)}, nil
+}
+
+func (s *CompaniesService) UpdateCompany(
+	ctx context.Context,
+	req *companiesv1.UpdateCompanyRequest,
+) (*companiesv1.UpdateCompanyResponse, error) {
+	var v validator
+	var updates model.UpdateCompanyData
+	switch identifier := req.GetIdentifier().(type) {
+	case *companiesv1.UpdateCompanyRequest_Id:
+		id := v.uuid("/id", identifier.Id)
+		updates.ID = &id
+	case *companiesv1.UpdateCompanyRequest_Name:
+		updates.Name = &identifier.Name
+	default:
+		v.add("/id", "id or name should be provided")
+	}
+	if req.NewName != nil {
+		v.name("/new_name", req.GetNewName())
+	}
+	if req.Description != nil {
+		v.description("/description", req.GetDescription())
+		updates.Description = req.Description
+	}
+	if req.EmployeesCount != nil {
+		v.employeesCount("/employees_count", req.GetEmployeesCount())
+		employeesCount := int(req.GetEmployeesCount())
+		updates.EmployeesCount = &employeesCount
+	}
+	if req.Type != nil {
+		companyType := v.companyType("/type", req.GetType())
+		updates.Type = &companyType
+	}
+	updates.Registered = req.Registered
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	if req.NewName != nil {
+		if updates.ID != nil {
+			updates.Name = req.NewName
+		} else {
+			// the company is looked up by its name within the update
+			updates.NewName = req.NewName
+		}
+	}
+
+	renames := updates.NewName != nil || updates.ID != nil && updates.Name != nil
+	if !renames && updates.Description == nil && updates.EmployeesCount == nil && updates.Registered == nil && updates.Type == nil {
+		return nil, apperrors.NewBadRequestError("no fields to update")
+	}
+
+	updated, err := s.cc.UpdateCompany(ctx, updates, companies.ExpectedVersion(toIntPtr(req.ExpectedVersion)))
+	if err != nil {
+		return nil, err
+	}
+
+	return &companiesv1.UpdateCompanyResponse{Company: toProtoCompany(updated)}, nil
+}
+
+func (s *CompaniesService) DeleteCompany(
+	ctx context.Context,
+	req *companiesv1.DeleteCompanyRequest,
+) (*companiesv1.DeleteCompanyResponse, error) {
+	var v validator
+	id := v.optionalUUID("/id", req.GetId())
+	if req.GetIdentifier() == nil {
+		v.add("/id", "id or name should be provided")
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	if err := s.cc.DeleteCompany(ctx, id, req.GetName(), companies.ExpectedVersion(toIntPtr(req.ExpectedVersion))); err != nil {
+		return nil, err
+	}
+
+	return &companiesv1.DeleteCompanyResponse{}, nil
+}
+
+func (s *CompaniesService) ListCompanies(
+	ctx context.Context,
+	req *companiesv1.ListCompaniesRequest,
+) (*companiesv1.ListCompaniesResponse, error) {
+	var v validator
+	params := model.ListCompaniesParams{
+		Filter: model.ListCompaniesFilter{
+			Registered:        req.Registered,
+			MinEmployeesCount: toIntPtr(req.MinEmployeesCount),
+			MaxEmployeesCount: toIntPtr(req.MaxEmployeesCount),
+		},
+		SortBy:    model.SortByName,
+		SortOrder: model.SortOrderAsc,
+		Limit:     defaultPageSize,
+		Cursor:    req.GetPageToken(),
+		WithTotal: req.GetWithTotal(),
+	}
+	if req.Type != nil {
+		companyType := v.companyType("/type", req.GetType())
+		params.Filter.Type = &companyType
+	}
+	if req.GetOrderBy() != "" {
+		params.SortBy = model.CompanySortField(req.GetOrderBy())
+		if !params.SortBy.IsValid() {
+			v.add("/order_by", "must be one of name, employees_count, created_at, updated_at")
+		}
+	}
+	if req.GetDescending() {
+		params.SortOrder = model.SortOrderDesc
+	}
+	if req.GetPageSize() != 0 {
+		if req.GetPageSize() < 1 || req.GetPageSize() > maxPageSize {
+			v.add("/page_size", fmt.Sprintf("must be between 1 and %d", maxPageSize))
+		}
+		params.Limit = int(req.GetPageSize())
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	page, err := s.cc.ListCompanies(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &companiesv1.ListCompaniesResponse{
+		Companies:     make([]*companiesv1.Company, 0, len(page.Companies)),
+		NextPageToken: page.NextCursor,
+	}
+	for _, company := range page.Companies {
+		resp.Companies = append(resp.Companies, toProtoCompany(company))
+	}
+	if page.Total != nil {
+		total := int32(*page.Total)
+		resp.Total = &total
+	}
+
+	return resp, nil
+}
+
+func toProtoCompany(company model.Company) *companiesv1.Company {
+	res := &companiesv1.Company{
+		Id:             company.ID.String(),
+		Name:           company.Name,
+		Description:    company.Description,
+		EmployeesCount: int32(company.EmployeesCount),
+		Registered:     company.Registered,
+		Version:        int32(company.Version),
+		CreateTime:     timestamppb.New(company.CreatedAt),
+		UpdateTime:     timestamppb.New(company.UpdatedAt),
+	}
+	for protoType, modelType := range companyTypes {
+		if modelType == company.Type {
+			res.Type = protoType
+		}
+	}
+	return res
+}
+
+func toIntPtr(v *int32) *int {
+	if v == nil {
+		return nil
+	}
+	res := int(*v)
+	return &res
+}
+
+// validator collects field errors the same way the JSON schema validation of the REST API reports them.
+type validator struct {
+	details []apperrors.FieldError
+}
+
+func (v *validator) add(pointer, message string) {
+	v.details = append(v.details, apperrors.FieldError{Pointer: pointer, Message: message})
+}
+
+func (v *validator) err() error {
+	if len(v.details) == 0 {
+		return nil
+	}
+	return apperrors.NewValidationError("request doesn't match the schema", v.details)
+}
+
+func (v *validator) uuid(pointer, value string) uuid.UUID {
+	id, err := uuid.Parse(value)
+	if err != nil {
+		v.add(pointer, "Does not match format 'uuid'")
+	}
+	return id
+}
+
+func (v *validator) optionalUUID(pointer, value string) uuid.UUID {
+	if value == "" {
+		return uuid.Nil
+	}
+	return v.uuid(pointer, value)
+}
+
+func (v *validator) name(pointer, value string) {
+	if value == "" {
+		v.add(pointer, "name is required")
+	} else if utf8.RuneCountInString(value) > maxNameLength {
+		v.add(pointer, fmt.Sprintf("String length must be less than or equal to %d", maxNameLength))
+	}
+}
+
+func (v *validator) description(pointer, value string) {
+	if utf8.RuneCountInString(value) > maxDescriptionLength {
+		v.add(pointer, fmt.Sprintf("String length must be less than or equal to %d", maxDescriptionLength))
+	}
+}
+
+func (v *validator) employeesCount(pointer string, value int32) {
+	if value < 1 {
+		v.add(pointer, "Must be greater than or equal to 1")
+	}
+}
+
+func (v *validator) companyType(pointer string, value companiesv1.CompanyType) model.CompanyType {
+	companyType, ok := companyTypes[value]
+	if !ok {
+		v.add(pointer, "type must be one of the defined company types")
+	}
+	return companyType
+}
@@ -0,0 +1,45 @@
+package grpcapi
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+type Config struct {
+	Addr string
+	// ShutdownTimeout bounds the wait for the in-flight calls on shutdown, the ones still
+	// running after it are aborted.
+	ShutdownTimeout time.Duration
+	// HealthInterval is how often the readiness checks are run to update the serving status
+	// reported by the gRPC health service.
+	HealthInterval time.Duration
+}
+
+func LoadGRPCConfig() *Config {
+	viper.SetDefault("grpc.addr", ":9090")
+	viper.SetDefault("grpc.shutdown_timeout", "15s")
+	viper.SetDefault("grpc.health_interval", "5s")
+
+	return &Config{
+		Addr:            viper.GetString("grpc.addr"),
+		ShutdownTimeout: viper.GetDuration("grpc.shutdown_timeout"),
+		HealthInterval:  viper.GetDuration("grpc.health_interval"),
+	}
+}
+
+func (c *Config) Validate() error {
+	var errs []error
+	if c.Addr == "" {
+		errs = append(errs, errors.New("grpc.addr is required"))
+	}
+	if c.ShutdownTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("grpc.shutdown_timeout must be positive, got %s", c.ShutdownTimeout))
+	}
+	if c.HealthInterval <= 0 {
+		errs = append(errs, fmt.Errorf("grpc.health_interval must be positive, got %s", c.HealthInterval))
+	}
+	return errors.Join(errs...)
+}
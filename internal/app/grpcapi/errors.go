@@ -0,0 +1,61 @@
+package grpcapi
+
+import (
+	"net/http"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+
+	"github.com/faeelol/companies-store/internal/app/apperrors"
+)
+
+const errorDomain = "companies-store"
+
+// grpcCodes maps the HTTP statuses of application errors to gRPC codes.
+var grpcCodes = map[int]codes.Code{
+	http.StatusBadRequest:          codes.InvalidArgument,
+	http.StatusUnauthorized:        codes.Unauthenticated,
+	http.StatusForbidden:           codes.PermissionDenied,
+	http.StatusNotFound:            codes.NotFound,
+	http.StatusConflict:            codes.Aborted,
+	http.StatusPreconditionFailed:  codes.FailedPrecondition,
+	http.StatusUnprocessableEntity: codes.InvalidArgument,
+	http.StatusInternalServerError: codes.Internal,
+	http.StatusServiceUnavailable:  codes.Unavailable,
+}
+
+// toStatus converts an error into a gRPC status. The error code travels as ErrorInfo reason
+// and the field errors as BadRequest field violations.
+func toStatus(err error) error {
+	appErr := apperrors.MapToAppError(err)
+
+	code, ok := grpcCodes[appErr.Code]
+	if !ok {
+		code = codes.Unknown
+	}
+	if appErr.ErrorCode == apperrors.CodeDuplicateCompany {
+		code = codes.AlreadyExists
+	}
+
+	st := status.New(code, appErr.Message)
+	details := []protoadapt.MessageV1{
+		&errdetails.ErrorInfo{Reason: appErr.ErrorCode, Domain: errorDomain},
+	}
+	if len(appErr.Details) > 0 {
+		badRequest := &errdetails.BadRequest{}
+		for _, fieldErr := range appErr.Details {
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       fieldErr.Pointer,
+				Description: fieldErr.Message,
+			})
+		}
+		details = append(details, badRequest)
+	}
+
+	if withDetails, err := st.WithDetails(details...); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
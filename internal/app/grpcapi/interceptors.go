@@ -0,0 +1,172 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/faeelol/companies-store/internal/app/apperrors"
+	"github.com/faeelol/companies-store/internal/app/rest/middlewares"
+	"github.com/faeelol/companies-store/internal/app/tracing"
+)
+
+const (
+	metadataAuthorization = "authorization"
+	metadataRequestID     = "x-request-id"
+)
+
+// inFlightInterceptor counts the calls being served.
+func inFlightInterceptor(inFlight *atomic.Int64) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		inFlight.Add(1)
+		defer inFlight.Add(-1)
+		return handler(ctx, req)
+	}
+}
+
+// tracingInterceptor records every call as a server span, continuing the trace of the traceparent
+// metadata when there is one.
+func tracingInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+
+	service, method, _ := strings.Cut(strings.TrimPrefix(info.FullMethod, "/"), "/")
+	ctx, span := tracing.Tracer().Start(ctx, strings.TrimPrefix(info.FullMethod, "/"),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(semconv.RPCSystemGRPC, semconv.RPCService(service), semconv.RPCMethod(method)),
+	)
+	defer span.End()
+
+	resp, err := handler(ctx, req)
+
+	code := status.Code(err)
+	span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(code)))
+	switch code {
+	case codes.Unknown, codes.Internal, codes.Unavailable, codes.DataLoss:
+		span.SetStatus(otelcodes.Error, status.Convert(err).Message())
+	}
+	return resp, err
+}
+
+// metadataCarrier lets the propagators read and write the gRPC metadata.
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if values := metadata.MD(c).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// recoveryInterceptor turns a panic of a handler into an Internal error.
+func recoveryInterceptor(logger logrus.FieldLogger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.WithFields(logrus.Fields{
+					"method": info.FullMethod,
+					"error":  fmt.Sprintf("%v", r),
+				}).Error("Unhandled error")
+				err = status.Error(codes.Internal, "Internal Server Error")
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// loggingInterceptor puts the request id and a request scoped logger into the context, the same
+// way the HTTP logging middleware does, and logs every call.
+func loggingInterceptor(logger logrus.FieldLogger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+
+		requestID := firstMetadataValue(ctx, metadataRequestID)
+		if requestID == "" {
+			requestID = middlewares.NewRequestID()
+		}
+		_ = grpc.SetHeader(ctx, metadata.Pairs(metadataRequestID, requestID))
+
+		reqLogger := logger.WithFields(logrus.Fields{
+			"request_id": requestID,
+			"method":     info.FullMethod,
+		})
+		ctx = middlewares.NewContextWithRequestID(ctx, requestID)
+		ctx = middlewares.NewContextWithLogger(ctx, reqLogger)
+
+		resp, err := handler(ctx, req)
+
+		duration := time.Since(start)
+		reqLogger.WithFields(logrus.Fields{
+			"duration_ms": duration.Milliseconds(),
+			"code":        status.Code(err).String(),
+		}).Info(fmt.Sprintf("call completed in %.3fs", duration.Seconds()))
+
+		return resp, err
+	}
+}
+
+// authInterceptor verifies the bearer token of the protected methods with the same rules
+// as the HTTP JWT middleware.
+func authInterceptor(auth *middlewares.JWTMiddleware, protectedMethods map[string]bool) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if !protectedMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		claims, appErr := auth.Authenticate(firstMetadataValue(ctx, metadataAuthorization))
+		if appErr != nil {
+			return nil, toStatus(appErr)
+		}
+
+		return handler(middlewares.NewContextWithClaims(ctx, claims), req)
+	}
+}
+
+// errorInterceptor converts application errors returned by the handlers into gRPC statuses.
+func errorInterceptor(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	resp, err := handler(ctx, req)
+	if err == nil {
+		return resp, nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return nil, err
+	}
+
+	appErr := apperrors.MapToAppError(err)
+	if logger := middlewares.GetLoggerFromContext(ctx); logger != nil && appErr.Code >= http.StatusInternalServerError {
+		logger.WithField("error", err).WithField("cause", appErr.Err).Error("internal server error")
+	}
+	return nil, toStatus(appErr)
+}
+
+func firstMetadataValue(ctx context.Context, key string) string {
+	if values := metadata.ValueFromIncomingContext(ctx, key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
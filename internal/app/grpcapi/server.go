@@ -0,0 +1,164 @@
+// Package grpcapi defines the gRPC server exposing the companies API.
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+
+	"github.com/faeelol/companies-store/internal/app/health"
+	"github.com/faeelol/companies-store/internal/app/rest/jwt"
+	"github.com/faeelol/companies-store/internal/app/rest/middlewares"
+	companiesv1 "github.com/faeelol/companies-store/pkg/api/companies/v1"
+)
+
+// adminMethods require a token with the admin role, like the mutating REST endpoints.
+var adminMethods = map[string]bool{
+	companiesv1.CompaniesService_CreateCompany_FullMethodName: true,
+	companiesv1.CompaniesService_UpdateCompany_FullMethodName: true,
+	companiesv1.CompaniesService_DeleteCompany_FullMethodName: true,
+}
+
+type Server struct {
+	cfg          *Config
+	grpcServer   *grpc.Server
+	healthServer *grpchealth.Server
+	checker      *health.Checker
+	// inFlight counts the calls being served, for the shutdown summary.
+	inFlight *atomic.Int64
+}
+
+func NewServer(
+	cfg *Config,
+	jwtCfg *jwt.Config,
+	logger *logrus.Logger,
+	cc CompaniesController,
+	checker *health.Checker,
+) *Server {
+	authAdmin := middlewares.NewJWTMiddleware(jwt.NewJWTParser(*jwtCfg), []string{"admin"})
+
+	inFlight := &atomic.Int64{}
+	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		inFlightInterceptor(inFlight),
+		tracingInterceptor,
+		recoveryInterceptor(logger),
+		loggingInterceptor(logger),
+		authInterceptor(authAdmin, adminMethods),
+		errorInterceptor,
+	))
+
+	companiesv1.RegisterCompaniesServiceServer(grpcServer, NewCompaniesService(cc))
+
+	// the serving status follows the readiness once the server starts
+	healthServer := grpchealth.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+
+	reflection.Register(grpcServer)
+
+	s := &Server{cfg: cfg, grpcServer: grpcServer, healthServer: healthServer, checker: checker, inFlight: inFlight}
+	s.setServing(false)
+	return s
+}
+
+func (s *Server) Start(ctx context.Context, logger *logrus.Logger) error {
+	listener, err := net.Listen("tcp", s.cfg.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.cfg.Addr, err)
+	}
+
+	errChan := make(chan error, 1)
+
+	healthCtx, stopHealth := context.WithCancel(ctx)
+	defer stopHealth()
+	go s.watchHealth(healthCtx)
+
+	go func() {
+		logger.WithField("addr", s.cfg.Addr).Info("starting gRPC server")
+		if err := s.grpcServer.Serve(listener); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+			errChan <- fmt.Errorf("server error: %w", err)
+		}
+		close(errChan)
+	}()
+
+	select {
+	case <-ctx.Done():
+		s.shutdown(logger)
+		return nil
+
+	case err := <-errChan:
+		return err
+	}
+}
+
+// watchHealth maps the readiness checks onto the serving status of the health service, and turns
+// it to NOT_SERVING for good as soon as the process starts draining, so the clients stop picking
+// the process during the shutdown delay.
+func (s *Server) watchHealth(ctx context.Context) {
+	ticker := time.NewTicker(s.cfg.HealthInterval)
+	defer ticker.Stop()
+
+	for {
+		s.setServing(s.checker.Ready(ctx).Status == health.StatusReady)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.checker.Drained():
+			s.setServing(false)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// setServing sets the status of the server and of the companies service.
+func (s *Server) setServing(serving bool) {
+	status := healthpb.HealthCheckResponse_NOT_SERVING
+	if serving {
+		status = healthpb.HealthCheckResponse_SERVING
+	}
+	s.healthServer.SetServingStatus("", status)
+	s.healthServer.SetServingStatus(companiesv1.CompaniesService_ServiceDesc.ServiceName, status)
+}
+
+// shutdown stops accepting connections and waits for the in-flight calls up to the shutdown
+// timeout, aborting the remaining ones.
+func (s *Server) shutdown(logger *logrus.Logger) {
+	start := time.Now()
+	inFlight := s.inFlight.Load()
+	logger.WithField("in_flight", inFlight).Info("shutting down gRPC service...")
+	s.healthServer.Shutdown()
+
+	stopped := make(chan struct{})
+	go func() {
+		s.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	timer := time.NewTimer(s.cfg.ShutdownTimeout)
+	defer timer.Stop()
+
+	var aborted int64
+	select {
+	case <-stopped:
+	case <-timer.C:
+		aborted = s.inFlight.Load()
+		s.grpcServer.Stop()
+	}
+
+	logger.WithFields(logrus.Fields{
+		"in_flight": inFlight,
+		"drained":   inFlight - aborted,
+		"aborted":   aborted,
+		"duration":  time.Since(start).String(),
+	}).Info("gRPC service stopped")
+}
@@ -0,0 +1,38 @@
+package health
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+type Config struct {
+	// Timeout bounds every readiness check.
+	Timeout time.Duration
+	// ShutdownDelay is how long the services keep running once the readiness turned to draining,
+	// so the load balancers stop sending requests before the servers stop accepting them.
+	ShutdownDelay time.Duration
+}
+
+func LoadHealthConfig() *Config {
+	viper.SetDefault("health.timeout", "2s")
+	viper.SetDefault("health.shutdown_delay", "0s")
+
+	return &Config{
+		Timeout:       viper.GetDuration("health.timeout"),
+		ShutdownDelay: viper.GetDuration("health.shutdown_delay"),
+	}
+}
+
+func (c *Config) Validate() error {
+	var errs []error
+	if c.Timeout <= 0 {
+		errs = append(errs, fmt.Errorf("health.timeout must be positive, got %s", c.Timeout))
+	}
+	if c.ShutdownDelay < 0 {
+		errs = append(errs, fmt.Errorf("health.shutdown_delay must not be negative, got %s", c.ShutdownDelay))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,195 @@
+// Package health reports whether the process is alive and ready to serve.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Statuses of the readiness report.
+const (
+	StatusOK       = "ok"
+	StatusFailed   = "failed"
+	StatusReady    = "ready"
+	StatusNotReady = "not_ready"
+	StatusDraining = "draining"
+)
+
+// Check is a dependency the process needs to serve. An optional check is reported, but its
+// failure doesn't make the process not ready.
+type Check struct {
+	Name     string
+	Run      func(ctx context.Context) error
+	Optional bool
+}
+
+// CheckResult is the outcome of a check.
+type CheckResult struct {
+	Name       string  `json:"name"`
+	Status     string  `json:"status"`
+	Error      string  `json:"error,omitempty"`
+	Optional   bool    `json:"optional,omitempty"`
+	DurationMs float64 `json:"duration_ms"`
+}
+
+// Report is the readiness of the process.
+type Report struct {
+	Status string        `json:"status"`
+	Checks []CheckResult `json:"checks"`
+}
+
+// Checker runs the readiness checks. Once draining, the process reports itself not ready
+// whatever the checks say, while it finishes serving before shutting down.
+type Checker struct {
+	cfg      *Config
+	checks   []Check
+	draining atomic.Bool
+	// drainedAt is when the draining started, in Unix nanoseconds.
+	drainedAt atomic.Int64
+	// drained is closed when the draining starts.
+	drained chan struct{}
+}
+
+func NewChecker(cfg *Config, checks ...Check) *Checker {
+	return &Checker{
+		cfg:     cfg,
+		checks:  checks,
+		drained: make(chan struct{}),
+	}
+}
+
+// Drain makes the readiness fail from now on.
+func (c *Checker) Drain() {
+	if c.draining.CompareAndSwap(false, true) {
+		c.drainedAt.Store(time.Now().UnixNano())
+		close(c.drained)
+	}
+}
+
+// Drained returns a channel closed when the draining starts.
+func (c *Checker) Drained() <-chan struct{} {
+	return c.drained
+}
+
+// SinceDrain returns the time elapsed since the draining started, zero when it hasn't.
+func (c *Checker) SinceDrain() time.Duration {
+	if !c.draining.Load() {
+		return 0
+	}
+	return time.Since(time.Unix(0, c.drainedAt.Load()))
+}
+
+// Ready runs the checks concurrently, each within the timeout.
+func (c *Checker) Ready(ctx context.Context) Report {
+	results := make([]CheckResult, len(c.checks))
+	var wg sync.WaitGroup
+	for i, check := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = c.run(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusReady, Checks: results}
+	for _, result := range results {
+		if result.Status != StatusOK && !result.Optional {
+			report.Status = StatusNotReady
+		}
+	}
+	if c.draining.Load() {
+		report.Status = StatusDraining
+	}
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, check Check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Run(ctx)
+	result := CheckResult{
+		Name:       check.Name,
+		Status:     StatusOK,
+		Optional:   check.Optional,
+		DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = StatusFailed
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// LivenessHandler responds as long as the process can serve requests, the dependencies aren't
+// checked: a restart wouldn't bring them back.
+func (c *Checker) LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		respond(rw, http.StatusOK, map[string]string{"status": StatusOK})
+	})
+}
+
+// ReadinessHandler responds 200 when all the checks pass, and 503 otherwise, with the status only
+// so it can be exposed publicly.
+func (c *Checker) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		report := c.Ready(r.Context())
+		respond(rw, readinessCode(report), map[string]string{"status": report.Status})
+	})
+}
+
+// ReportHandler responds like ReadinessHandler, with the outcome of every check and the errors
+// of the failed ones. It belongs on the admin listener.
+func (c *Checker) ReportHandler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		report := c.Ready(r.Context())
+		respond(rw, readinessCode(report), report)
+	})
+}
+
+func readinessCode(report Report) int {
+	if report.Status != StatusReady {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusOK
+}
+
+func respond(rw http.ResponseWriter, code int, body any) {
+	rw.Header().Set("Content-Type", "application/json")
+	// probes must see the current state
+	rw.Header().Set("Cache-Control", "no-store")
+	rw.WriteHeader(code)
+	_ = json.NewEncoder(rw).Encode(body)
+}
+
+// DrainContext returns a context done ShutdownDelay after ctx is, the readiness failing in between.
+// The services run on it so they keep serving while the load balancers take the process out.
+func (c *Checker) DrainContext(ctx context.Context, logger logrus.FieldLogger) (context.Context, context.CancelFunc) {
+	drainCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-drainCtx.Done():
+			return
+		}
+
+		c.Drain()
+		logger.WithField("shutdown_delay", c.cfg.ShutdownDelay.String()).Info("shutting down, readiness is draining")
+		timer := time.NewTimer(c.cfg.ShutdownDelay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-drainCtx.Done():
+		}
+		cancel()
+	}()
+	return drainCtx, cancel
+}
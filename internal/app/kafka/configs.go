@@ -1,6 +1,11 @@
 package kafka
 
 import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
 	"github.com/spf13/viper"
 )
 
@@ -10,8 +15,31 @@ type Config struct {
 }
 
 func LoadKafkaConfig() *Config {
+	viper.SetDefault("kafka.brokers", []string{"localhost:9092"})
+	viper.SetDefault("kafka.topic", "company_events")
+
 	return &Config{
 		Brokers: viper.GetStringSlice("kafka.brokers"),
 		Topic:   viper.GetString("kafka.topic"),
 	}
 }
+
+func (c *Config) Validate() error {
+	var errs []error
+	if len(c.Brokers) == 0 {
+		errs = append(errs, errors.New("kafka.brokers needs at least one broker"))
+	}
+	for _, broker := range c.Brokers {
+		if _, _, err := net.SplitHostPort(broker); err != nil {
+			msg := fmt.Sprintf("kafka.brokers has an invalid host:port %q", broker)
+			if strings.Contains(broker, ",") {
+				msg += ", the lists set in the environment are space-separated"
+			}
+			errs = append(errs, errors.New(msg))
+		}
+	}
+	if c.Topic == "" {
+		errs = append(errs, errors.New("kafka.topic is required"))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,68 @@
+package kafka
+
+import (
+	"context"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Message is a message read from or written to a topic.
+type Message struct {
+	Topic     string
+	Partition int
+	Offset    int64
+	Key       []byte
+	Value     []byte
+	Headers   map[string]string
+}
+
+// Consumer reads a topic as a member of a consumer group. Offsets are only committed
+// explicitly, so a message is read again until it's committed.
+type Consumer struct {
+	reader *kafka.Reader
+}
+
+func NewConsumer(cfg *Config, topic, groupID string) *Consumer {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers: cfg.Brokers,
+		Topic:   topic,
+		GroupID: groupID,
+	})
+
+	return &Consumer{reader: reader}
+}
+
+// Fetch blocks until the next message is available or ctx is done.
+func (c *Consumer) Fetch(ctx context.Context) (Message, error) {
+	msg, err := c.reader.FetchMessage(ctx)
+	if err != nil {
+		return Message{}, err
+	}
+
+	headers := make(map[string]string, len(msg.Headers))
+	for _, h := range msg.Headers {
+		headers[h.Key] = string(h.Value)
+	}
+
+	return Message{
+		Topic:     msg.Topic,
+		Partition: msg.Partition,
+		Offset:    msg.Offset,
+		Key:       msg.Key,
+		Value:     msg.Value,
+		Headers:   headers,
+	}, nil
+}
+
+// Commit commits the offset of the message, and so of all the previous messages of its partition.
+func (c *Consumer) Commit(ctx context.Context, msg Message) error {
+	return c.reader.CommitMessages(ctx, kafka.Message{
+		Topic:     msg.Topic,
+		Partition: msg.Partition,
+		Offset:    msg.Offset,
+	})
+}
+
+func (c *Consumer) Close() error {
+	return c.reader.Close()
+}
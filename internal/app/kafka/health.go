@@ -0,0 +1,41 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// CheckTopic checks a broker is reachable and the topic has partitions.
+func CheckTopic(ctx context.Context, cfg *Config, topic string) error {
+	if len(cfg.Brokers) == 0 {
+		return errors.New("no kafka brokers configured")
+	}
+
+	var dialer kafka.Dialer
+	var errs []error
+	// any broker answers the metadata request
+	for _, broker := range cfg.Brokers {
+		conn, err := dialer.DialContext(ctx, "tcp", broker)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if deadline, ok := ctx.Deadline(); ok {
+			_ = conn.SetDeadline(deadline)
+		}
+		partitions, err := conn.ReadPartitions(topic)
+		_ = conn.Close()
+		if err != nil {
+			return fmt.Errorf("read topic %s metadata: %w", topic, err)
+		}
+		if len(partitions) == 0 {
+			return fmt.Errorf("topic %s has no partitions", topic)
+		}
+		return nil
+	}
+	return fmt.Errorf("no kafka broker reachable: %w", errors.Join(errs...))
+}
@@ -3,51 +3,197 @@ package kafka
 
 import (
 	"context"
+	"errors"
 	"log"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/segmentio/kafka-go"
-)
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 
-const (
-	CreateCompanyEvent = "create_company"
-	DeleteCompanyEvent = "delete_company"
-	UpdateCompanyEvent = "update_company"
+	"github.com/faeelol/companies-store/internal/app/tracing"
 )
 
 type Producer struct {
 	writer *kafka.Writer
 	topic  string
+
+	published atomic.Int64
+	failed    atomic.Int64
 }
 
+// ProducerStats are the stats of a producer since they were last read.
+type ProducerStats struct {
+	Topic string
+	// Published and Failed count the messages Publish and PublishBatch succeeded and failed to write.
+	Published int64
+	Failed    int64
+	Writer    kafka.WriterStats
+}
+
+// producers are the open producers, their stats are read by Stats.
+var producers sync.Map
+
+// Stats returns the stats of the open producers since the previous call. The counters are reset
+// by every call, so it should have a single caller.
+func Stats() []ProducerStats {
+	var stats []ProducerStats
+	producers.Range(func(key, _ any) bool {
+		p := key.(*Producer)
+		stats = append(stats, ProducerStats{
+			Topic:     p.topic,
+			Published: p.published.Swap(0),
+			Failed:    p.failed.Swap(0),
+			Writer:    p.writer.Stats(),
+		})
+		return true
+	})
+	return stats
+}
+
+// NewProducer creates a producer of the events topic.
 func NewProducer(cfg *Config) *Producer {
+	return NewTopicProducer(cfg, cfg.Topic)
+}
+
+func NewTopicProducer(cfg *Config, topic string) *Producer {
+	// murmur2 is the balancer of the Java client, so every client sends a key to the same partition
 	writer := &kafka.Writer{
 		Addr:         kafka.TCP(cfg.Brokers...),
-		Topic:        cfg.Topic,
-		Balancer:     &kafka.LeastBytes{},
+		Topic:        topic,
+		Balancer:     &kafka.Murmur2Balancer{},
 		RequiredAcks: kafka.RequireAll,
 		Async:        false,
+		// writes are synchronous, they would otherwise wait up to a second for a batch to fill up
+		BatchTimeout: 10 * time.Millisecond,
 	}
 
-	return &Producer{
+	p := &Producer{
 		writer: writer,
-		topic:  cfg.Topic,
+		topic:  topic,
 	}
+	producers.Store(p, struct{}{})
+	return p
 }
 
-func (p *Producer) Publish(ctx context.Context, key string, value []byte) error {
+// Publish writes a message to the topic. Messages with the same key go to the same partition,
+// which keeps them in order. A nil value is written as a tombstone.
+func (p *Producer) Publish(ctx context.Context, key string, value []byte, headers map[string]string) (err error) {
+	ctx, span := p.startSpan(ctx, 1)
+	defer func() {
+		tracing.End(span, err)
+	}()
+	span.SetAttributes(semconv.MessagingKafkaMessageKey(key))
+
 	msg := kafka.Message{
-		Key:   []byte(key),
-		Value: value,
-		Time:  time.Now(),
+		Key:     []byte(key),
+		Value:   value,
+		Headers: toHeaders(withTraceContext(ctx, headers)),
+		Time:    time.Now(),
 	}
-	err := p.writer.WriteMessages(ctx, msg)
+	err = p.writer.WriteMessages(ctx, msg)
+	p.count(1, err)
 	if err != nil {
 		log.Printf("Failed to publish message to Kafka: %v", err)
 	}
 	return err
 }
 
+// PublishBatch writes the messages in as few requests as possible. Messages with the same key
+// keep their order. The topic of the messages is ignored, they go to the topic of the producer.
+func (p *Producer) PublishBatch(ctx context.Context, msgs []Message) (err error) {
+	ctx, span := p.startSpan(ctx, len(msgs))
+	defer func() {
+		tracing.End(span, err)
+	}()
+
+	batch := make([]kafka.Message, 0, len(msgs))
+	now := time.Now()
+	for _, msg := range msgs {
+		batch = append(batch, kafka.Message{
+			Key:     msg.Key,
+			Value:   msg.Value,
+			Headers: toHeaders(withTraceContext(ctx, msg.Headers)),
+			Time:    now,
+		})
+	}
+
+	err = p.writer.WriteMessages(ctx, batch...)
+	p.count(len(batch), err)
+	if err != nil {
+		log.Printf("Failed to publish messages to Kafka: %v", err)
+	}
+	return err
+}
+
+// startSpan starts the span of a write, within the trace of ctx.
+func (p *Producer) startSpan(ctx context.Context, messages int) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{
+		semconv.MessagingSystemKafka,
+		semconv.MessagingOperationTypePublish,
+		semconv.MessagingDestinationName(p.topic),
+	}
+	if messages > 1 {
+		attrs = append(attrs, semconv.MessagingBatchMessageCount(messages))
+	}
+	return tracing.StartSpan(ctx, p.topic+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// withTraceContext returns the headers with the trace context of ctx, so the consumers continue
+// the trace. The headers given are left as they are.
+func withTraceContext(ctx context.Context, headers map[string]string) map[string]string {
+	if !tracing.InTrace(ctx) {
+		return headers
+	}
+	res := make(map[string]string, len(headers)+1)
+	for k, v := range headers {
+		res[k] = v
+	}
+	tracing.Inject(ctx, res)
+	return res
+}
+
+// count records the outcome of a write. A failed batch may be partly written, the messages
+// written are told apart by the kafka.WriteErrors.
+func (p *Producer) count(messages int, err error) {
+	var writeErrs kafka.WriteErrors
+	switch {
+	case err == nil:
+		p.published.Add(int64(messages))
+	case errors.As(err, &writeErrs):
+		p.failed.Add(int64(writeErrs.Count()))
+		p.published.Add(int64(messages - writeErrs.Count()))
+	default:
+		p.failed.Add(int64(messages))
+	}
+}
+
 func (p *Producer) Close() error {
+	producers.Delete(p)
 	return p.writer.Close()
 }
+
+func toHeaders(headers map[string]string) []kafka.Header {
+	if len(headers) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(headers))
+	for k := range headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	res := make([]kafka.Header, 0, len(keys))
+	for _, k := range keys {
+		res = append(res, kafka.Header{Key: k, Value: []byte(headers[k])})
+	}
+	return res
+}
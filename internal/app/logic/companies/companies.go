@@ -4,53 +4,177 @@ package companies
 import (
 	"context"
 	"encoding/json"
+	"net/http"
+	"slices"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 
+	"github.com/faeelol/companies-store/internal/app/apperrors"
 	"github.com/faeelol/companies-store/internal/app/database"
 	"github.com/faeelol/companies-store/internal/app/database/repositories"
-	"github.com/faeelol/companies-store/internal/app/kafka"
+	"github.com/faeelol/companies-store/internal/app/events"
 	"github.com/faeelol/companies-store/internal/app/model"
 	"github.com/faeelol/companies-store/internal/app/rest/middlewares"
+	"github.com/faeelol/companies-store/internal/app/tracing"
 )
 
-type EventsProducer interface {
-	Publish(ctx context.Context, key string, value []byte) error
+// ChangeNotifier is told about the events of the company changes once they're committed.
+type ChangeNotifier interface {
+	Publish(eventType events.Type, company model.Company, data []byte)
 }
 
 type Controller struct {
-	db          *sqlx.DB
-	companyRepo repositories.CompanyRepository
-	producer    EventsProducer
+	db           *sqlx.DB
+	companyRepo  repositories.CompanyRepository
+	revisionRepo repositories.RevisionRepository
+	outboxRepo   repositories.OutboxRepository
+	webhookRepo  repositories.WebhookRepository
+	events       *events.Builder
+	notifier     ChangeNotifier
 }
 
-func NewCompaniesController(db *sqlx.DB, companyRepo repositories.CompanyRepository, producer EventsProducer) *Controller {
+func NewCompaniesController(
+	db *sqlx.DB,
+	companyRepo repositories.CompanyRepository,
+	revisionRepo repositories.RevisionRepository,
+	outboxRepo repositories.OutboxRepository,
+	webhookRepo repositories.WebhookRepository,
+	eventBuilder *events.Builder,
+	notifier ChangeNotifier,
+) *Controller {
 	return &Controller{
-		db:          db,
-		companyRepo: companyRepo,
-		producer:    producer,
+		db:           db,
+		companyRepo:  companyRepo,
+		revisionRepo: revisionRepo,
+		outboxRepo:   outboxRepo,
+		webhookRepo:  webhookRepo,
+		events:       eventBuilder,
+		notifier:     notifier,
 	}
 }
 
-func (c *Controller) CreateCompany(ctx context.Context, company model.CreateCompanyData) error {
-	err := database.WithinTransaction(ctx, c.db, func(tx *sqlx.Tx) error {
-		return c.companyRepo.CreateCompany(ctx, tx, &repositories.Company{
-			ID:             company.ID,
-			Name:           company.Name,
-			Description:    &company.Description,
-			EmployeesCount: company.EmployeesCount,
-			Registered:     company.Registered,
-			Type:           repositories.CompanyType(company.Type),
-		})
+func (c *Controller) CreateCompany(ctx context.Context, company model.CreateCompanyData) (model.Company, error) {
+	var created model.Company
+
+	if err := assignCompanyID(&company); err != nil {
+		return model.Company{}, err
+	}
+
+	err := c.withinTransaction(ctx, func(tx *sqlx.Tx, pending *pendingEvents) error {
+		var err error
+		created, err = c.companyRepo.CreateCompany(ctx, tx, toRepositoryCompany(company))
+		if err != nil {
+			return err
+		}
+		if err := c.recordRevision(ctx, tx, model.RevisionCreate, created); err != nil {
+			return err
+		}
+		if err := c.enqueueEvent(ctx, tx, pending, events.CompanyCreated, nil, &created); err != nil {
+			return err
+		}
+		return runCommitHook(ctx, tx, created)
 	})
 	if err != nil {
-		return err
+		return model.Company{}, err
 	}
 
-	c.PublishEvent(ctx, kafka.CreateCompanyEvent, company.ID.String(), "name", company)
+	return created, nil
+}
+
+// BulkUpsertCompanies stores every item in its own transaction, so one failing item
+// doesn't affect the others, and reports the outcome per item.
+func (c *Controller) BulkUpsertCompanies(
+	ctx context.Context,
+	items []model.BulkCompanyItem,
+	mode model.ConflictMode,
+) []model.BulkItemResult {
+	results := make([]model.BulkItemResult, 0, len(items))
 
-	return err
+	for _, item := range items {
+		if err := assignCompanyID(&item.Company); err != nil {
+			result := model.BulkItemResult{Index: item.Index}
+			result.SetError(err)
+			results = append(results, result)
+			continue
+		}
+		id := item.Company.ID
+		result := model.BulkItemResult{Index: item.Index, ID: &id}
+
+		err := c.withinTransaction(ctx, func(tx *sqlx.Tx, pending *pendingEvents) error {
+			var before *model.Company
+			if mode == model.OnConflictUpsert {
+				current, err := c.companyRepo.GetCompanyForUpdate(ctx, tx, id, "")
+				if err == nil {
+					before = &current
+				} else if apperrors.MapToAppError(err).Code != http.StatusNotFound {
+					return err
+				}
+			}
+
+			var company model.Company
+			var err error
+			company, result.Status, err = c.companyRepo.UpsertCompany(ctx, tx, toRepositoryCompany(item.Company), mode)
+			if err != nil {
+				return err
+			}
+
+			switch result.Status {
+			case model.BulkItemCreated:
+				if err := c.recordRevision(ctx, tx, model.RevisionCreate, company); err != nil {
+					return err
+				}
+				return c.enqueueEvent(ctx, tx, pending, events.CompanyCreated, nil, &company)
+			case model.BulkItemUpdated:
+				if before != nil && company.Name != before.Name {
+					if err := c.companyRepo.AddNameAlias(ctx, tx, company.ID, before.Name, company.Name); err != nil {
+						return err
+					}
+				}
+				if err := c.recordRevision(ctx, tx, model.RevisionUpdate, company); err != nil {
+					return err
+				}
+				return c.enqueueEvent(ctx, tx, pending, events.CompanyUpdated, before, &company)
+			}
+			return nil
+		})
+		if err != nil {
+			appErr := apperrors.MapToAppError(err)
+			if logger := middlewares.GetLoggerFromContext(ctx); logger != nil && appErr.Code >= http.StatusInternalServerError {
+				logger.WithField("error", err).WithField("cause", appErr.Err).Error("failed to upsert company")
+			}
+			result.SetError(appErr)
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// assignCompanyID generates a time-ordered UUIDv7 for a company created without an id.
+func assignCompanyID(company *model.CreateCompanyData) error {
+	if company.ID != uuid.Nil {
+		return nil
+	}
+	id, err := uuid.NewV7()
+	if err != nil {
+		return apperrors.NewInternalServerError("failed to generate company id").WithCause(err)
+	}
+	company.ID = id
+	return nil
+}
+
+func toRepositoryCompany(company model.CreateCompanyData) *repositories.Company {
+	return &repositories.Company{
+		ID:             company.ID,
+		Name:           company.Name,
+		Description:    &company.Description,
+		EmployeesCount: company.EmployeesCount,
+		Registered:     company.Registered,
+		Type:           repositories.CompanyType(company.Type),
+	}
 }
 
 func (c *Controller) GetCompany(ctx context.Context, reqUUID uuid.UUID, name string) (model.Company, error) {
@@ -65,58 +189,367 @@ func (c *Controller) GetCompany(ctx context.Context, reqUUID uuid.UUID, name str
 	return company, err
 }
 
-func (c *Controller) DeleteCompany(ctx context.Context, reqUUID uuid.UUID, name string) error {
+func (c *Controller) ListCompanies(ctx context.Context, params model.ListCompaniesParams) (model.CompaniesPage, error) {
+	page := model.CompaniesPage{}
+
+	err := database.WithinTransaction(ctx, c.db, func(tx *sqlx.Tx) error {
+		var txErr error
+		page, txErr = c.companyRepo.ListCompanies(ctx, tx, params)
+		return txErr
+	})
+
+	return page, err
+}
+
+func (c *Controller) SearchCompanies(ctx context.Context, params model.SearchCompaniesParams) (model.CompanySearchPage, error) {
+	page := model.CompanySearchPage{}
+
 	err := database.WithinTransaction(ctx, c.db, func(tx *sqlx.Tx) error {
-		return c.companyRepo.DeleteCompany(ctx, tx, reqUUID, name)
+		var txErr error
+		page, txErr = c.companyRepo.SearchCompanies(ctx, tx, params)
+		return txErr
+	})
+
+	return page, err
+}
+
+// CountCompaniesByType counts the companies that aren't deleted by type.
+func (c *Controller) CountCompaniesByType(ctx context.Context) (map[model.CompanyType]int, error) {
+	var counts map[model.CompanyType]int
+
+	err := database.WithinTransaction(ctx, c.db, func(tx *sqlx.Tx) error {
+		var txErr error
+		counts, txErr = c.companyRepo.CountCompaniesByType(ctx, tx)
+		return txErr
+	})
+
+	return counts, err
+}
+
+// DeleteCompany removes the company. When expectedVersions is set, the company is only
+// deleted if its current version is one of them.
+func (c *Controller) DeleteCompany(ctx context.Context, reqUUID uuid.UUID, name string, expectedVersions []int) error {
+	return c.withinTransaction(ctx, func(tx *sqlx.Tx, pending *pendingEvents) error {
+		current, err := c.companyRepo.GetCompanyForUpdate(ctx, tx, reqUUID, name)
+		if err != nil {
+			return err
+		}
+		if err := checkVersion(current, expectedVersions); err != nil {
+			return err
+		}
+		deleted, err := c.companyRepo.DeleteCompany(ctx, tx, current.ID, "")
+		if err != nil {
+			return err
+		}
+		if err := c.recordRevision(ctx, tx, model.RevisionDelete, deleted); err != nil {
+			return err
+		}
+		if err := c.enqueueEvent(ctx, tx, pending, events.CompanyDeleted, &current, &deleted); err != nil {
+			return err
+		}
+		if err := c.enqueueTombstone(ctx, tx, deleted.ID); err != nil {
+			return err
+		}
+		return runCommitHook(ctx, tx, deleted)
+	})
+}
+
+// RestoreCompany brings back a soft-deleted company.
+func (c *Controller) RestoreCompany(ctx context.Context, reqUUID uuid.UUID) (model.Company, error) {
+	var restored model.Company
+
+	err := c.withinTransaction(ctx, func(tx *sqlx.Tx, pending *pendingEvents) error {
+		deleted, err := c.companyRepo.GetDeletedCompanyForUpdate(ctx, tx, reqUUID)
+		if err != nil {
+			return err
+		}
+		restored, err = c.companyRepo.RestoreCompany(ctx, tx, reqUUID)
+		if err != nil {
+			return err
+		}
+		if err := c.recordRevision(ctx, tx, model.RevisionRestore, restored); err != nil {
+			return err
+		}
+		if err := c.enqueueEvent(ctx, tx, pending, events.CompanyRestored, &deleted, &restored); err != nil {
+			return err
+		}
+		return runCommitHook(ctx, tx, restored)
 	})
 	if err != nil {
-		return err
+		return model.Company{}, err
 	}
 
-	if reqUUID != uuid.Nil {
-		c.PublishEvent(ctx, kafka.DeleteCompanyEvent, reqUUID.String(), "uuid", map[string]string{})
-	} else if name != "" {
-		c.PublishEvent(ctx, kafka.DeleteCompanyEvent, name, "name", map[string]string{})
-	}
+	return restored, nil
+}
 
-	return nil
+// PurgeDeletedCompanies permanently removes companies that were soft-deleted more than
+// olderThan ago. Companies are purged in batches, each in its own transaction, and the
+// number of purged companies is returned.
+func (c *Controller) PurgeDeletedCompanies(ctx context.Context, olderThan time.Duration, batchSize int) (int, error) {
+	purgedTotal := 0
+
+	for {
+		var purged []model.Company
+
+		err := c.withinTransaction(ctx, func(tx *sqlx.Tx, pending *pendingEvents) error {
+			var err error
+			purged, err = c.companyRepo.PurgeCompanies(ctx, tx, olderThan, batchSize)
+			if err != nil {
+				return err
+			}
+			for _, company := range purged {
+				if err := c.recordRevision(ctx, tx, model.RevisionPurge, company); err != nil {
+					return err
+				}
+				if err := c.enqueueEvent(ctx, tx, pending, events.CompanyPurged, &company, nil); err != nil {
+					return err
+				}
+				if err := c.enqueueTombstone(ctx, tx, company.ID); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return purgedTotal, err
+		}
+
+		purgedTotal += len(purged)
+		if len(purged) < batchSize {
+			return purgedTotal, nil
+		}
+	}
 }
 
+// UpdateCompany applies a partial update and returns the updated company. The company is looked up
+// by id when it's given, and then a name in the updates renames the company; otherwise the name is
+// used for the lookup, and NewName renames it. When expectedVersions is set, the update is only
+// applied if the current version of the company is one of them.
 func (c *Controller) UpdateCompany(
 	ctx context.Context,
 	updates model.UpdateCompanyData,
-) error {
+	expectedVersions []int,
+) (model.Company, error) {
+	var current, updated model.Company
+
+	err := c.withinTransaction(ctx, func(tx *sqlx.Tx, pending *pendingEvents) error {
+		var reqUUID uuid.UUID
+		var name string
+		if updates.ID != nil {
+			reqUUID = *updates.ID
+		} else if updates.Name != nil {
+			name = *updates.Name
+		}
+
+		var err error
+		current, err = c.companyRepo.GetCompanyForUpdate(ctx, tx, reqUUID, name)
+		if err != nil {
+			return err
+		}
+		if err := checkVersion(current, expectedVersions); err != nil {
+			return err
+		}
+		if updates.NewName != nil {
+			// the company is locked, so the one found by its name is the one renamed
+			updates.ID = &current.ID
+			updates.Name = updates.NewName
+		}
+
+		updated, err = c.companyRepo.UpdateCompany(ctx, tx, updates)
+		if err != nil {
+			return err
+		}
+		if updated.Name != current.Name {
+			if err := c.companyRepo.AddNameAlias(ctx, tx, updated.ID, current.Name, updated.Name); err != nil {
+				return err
+			}
+		}
+		if err := c.recordRevision(ctx, tx, model.RevisionUpdate, updated); err != nil {
+			return err
+		}
+		if err := c.enqueueEvent(ctx, tx, pending, events.CompanyUpdated, &current, &updated); err != nil {
+			return err
+		}
+		return runCommitHook(ctx, tx, updated)
+	})
+	if err != nil {
+		return model.Company{}, err
+	}
+
+	return updated, nil
+}
+
+// ListRevisions returns the change history of a company, newest first.
+func (c *Controller) ListRevisions(ctx context.Context, params model.ListRevisionsParams) (model.CompanyRevisionsPage, error) {
+	page := model.CompanyRevisionsPage{}
+
 	err := database.WithinTransaction(ctx, c.db, func(tx *sqlx.Tx) error {
-		return c.companyRepo.UpdateCompany(ctx, tx, updates)
+		var txErr error
+		page, txErr = c.revisionRepo.ListRevisions(ctx, tx, params)
+		return txErr
+	})
+
+	return page, err
+}
+
+// GetCompanyAsOf returns the company as it was at the given moment.
+func (c *Controller) GetCompanyAsOf(ctx context.Context, reqUUID uuid.UUID, asOf time.Time) (model.Company, error) {
+	company := model.Company{}
+
+	err := database.WithinTransaction(ctx, c.db, func(tx *sqlx.Tx) error {
+		var txErr error
+		company, txErr = c.revisionRepo.GetCompanyAsOf(ctx, tx, reqUUID, asOf)
+		return txErr
 	})
+
+	return company, err
+}
+
+// recordRevision stores a snapshot of the company together with the acting JWT subject
+// and the request ID, within the transaction that changed the company.
+func (c *Controller) recordRevision(ctx context.Context, tx *sqlx.Tx, operation model.RevisionOperation, company model.Company) error {
+	revision, err := repositories.NewRevision(
+		operation,
+		company,
+		middlewares.GetSubjectFromContext(ctx),
+		middlewares.GetRequestIDFromContext(ctx),
+	)
 	if err != nil {
-		return err
+		return apperrors.NewInternalServerError("failed to serialize company revision").WithCause(err)
 	}
+	return c.revisionRepo.CreateRevision(ctx, tx, revision)
+}
 
-	if updates.ID != nil {
-		c.PublishEvent(ctx, kafka.UpdateCompanyEvent, updates.ID.String(), "uuid", updates)
-	} else if updates.Name != nil {
-		c.PublishEvent(ctx, kafka.UpdateCompanyEvent, *updates.Name, "name", updates)
+// ExpectedVersion returns the version condition of a single expected version, none when it's nil.
+func ExpectedVersion(version *int) []int {
+	if version == nil {
+		return nil
 	}
+	return []int{*version}
+}
 
+func checkVersion(company model.Company, expectedVersions []int) error {
+	if expectedVersions != nil && !slices.Contains(expectedVersions, company.Version) {
+		return apperrors.NewPreconditionFailedError("company has been modified, version mismatch").
+			WithErrorCode(apperrors.CodeVersionMismatch)
+	}
 	return nil
 }
 
-func (c *Controller) PublishEvent(ctx context.Context, action string, identifier string, idType string, data any) {
-	event := map[string]any{
-		"action":     action,
-		"identifier": identifier,
-		"id_type":    idType,
-		"data":       data,
+// CommitHook is run within the transaction of a company change, once the change is made, with the
+// changed company. What it stores is committed along with the change, and its error rolls the change back.
+type CommitHook func(ctx context.Context, tx *sqlx.Tx, company model.Company) error
+
+type commitHookKey struct{}
+
+// NewContextWithCommitHook makes the hook run within the changes of a single company made with the
+// context: creating, updating, deleting or restoring it.
+func NewContextWithCommitHook(ctx context.Context, hook CommitHook) context.Context {
+	return context.WithValue(ctx, commitHookKey{}, hook)
+}
+
+func runCommitHook(ctx context.Context, tx *sqlx.Tx, company model.Company) error {
+	hook, ok := ctx.Value(commitHookKey{}).(CommitHook)
+	if !ok {
+		return nil
+	}
+	return hook(ctx, tx, company)
+}
+
+// pendingEvents are the events enqueued within a transaction, handed to the notifier once it's committed.
+type pendingEvents []pendingEvent
+
+type pendingEvent struct {
+	eventType events.Type
+	company   model.Company
+	data      []byte
+}
+
+// withinTransaction runs fn within a transaction, like database.WithinTransaction, and hands the
+// events fn enqueued to the notifier once the transaction is committed.
+func (c *Controller) withinTransaction(ctx context.Context, fn func(tx *sqlx.Tx, pending *pendingEvents) error) error {
+	var pending pendingEvents
+	err := database.WithinTransaction(ctx, c.db, func(tx *sqlx.Tx) error {
+		return fn(tx, &pending)
+	})
+	if err != nil {
+		return err
+	}
+
+	if c.notifier != nil {
+		for _, event := range pending {
+			c.notifier.Publish(event.eventType, event.company, event.data)
+		}
+	}
+	return nil
+}
+
+// enqueueEvent stores the event about the company change in the outbox, and its deliveries to the
+// webhook subscriptions, within the transaction changing the company, so the event is published
+// if and only if the change is committed. Events are keyed by the company id, which keeps the
+// events of a company in order. The event is added to pending for the notifier.
+func (c *Controller) enqueueEvent(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	pending *pendingEvents,
+	eventType events.Type,
+	before *model.Company,
+	after *model.Company,
+) error {
+	event, err := c.events.CompanyEvent(eventType, before, after)
+	if err != nil {
+		return apperrors.NewInternalServerError("failed to create event").WithCause(err)
 	}
 
 	eventBytes, err := json.Marshal(event)
 	if err != nil {
-		middlewares.GetLoggerFromContext(ctx).Errorf("failed to serialize event: %v", err)
-		return
+		return apperrors.NewInternalServerError("failed to serialize event").WithCause(err)
+	}
+
+	headers := messageHeaders(ctx)
+	headers[events.HeaderContentType] = events.ContentType
+	headers[events.HeaderType] = string(eventType)
+
+	err = c.outboxRepo.AddMessage(ctx, tx, &repositories.OutboxMessage{
+		Key:     event.Subject,
+		Payload: eventBytes,
+		Headers: headers,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := c.webhookRepo.AddDeliveries(ctx, tx, event.ID, string(eventType), eventBytes); err != nil {
+		return err
 	}
 
-	if err := c.producer.Publish(ctx, action, eventBytes); err != nil {
-		middlewares.GetLoggerFromContext(ctx).Errorf("failed to publish event to Kafka: %v", err)
+	company := after
+	if company == nil {
+		company = before
+	}
+	*pending = append(*pending, pendingEvent{eventType: eventType, company: *company, data: eventBytes})
+	return nil
+}
+
+// enqueueTombstone stores a tombstone of the company in the outbox, so compacted topics
+// drop the events of the company.
+func (c *Controller) enqueueTombstone(ctx context.Context, tx *sqlx.Tx, companyID uuid.UUID) error {
+	return c.outboxRepo.AddMessage(ctx, tx, &repositories.OutboxMessage{
+		Key:     companyID.String(),
+		Headers: messageHeaders(ctx),
+	})
+}
+
+// messageHeaders returns the headers identifying the request and the acting subject, along with
+// the trace context of the request so the relay continues its trace.
+func messageHeaders(ctx context.Context) repositories.OutboxHeaders {
+	headers := repositories.OutboxHeaders{}
+	tracing.Inject(ctx, headers)
+	if requestID := middlewares.GetRequestIDFromContext(ctx); requestID != "" {
+		headers[events.HeaderRequestID] = requestID
+	}
+	if actor := middlewares.GetSubjectFromContext(ctx); actor != "" {
+		headers[events.HeaderActor] = actor
 	}
+	return headers
 }
@@ -0,0 +1,202 @@
+// Package idempotency stores responses of requests made with an Idempotency-Key, so retries can be replayed.
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/faeelol/companies-store/internal/app/apperrors"
+	"github.com/faeelol/companies-store/internal/app/database"
+	"github.com/faeelol/companies-store/internal/app/database/repositories"
+	"github.com/faeelol/companies-store/internal/app/rest/middlewares"
+)
+
+type Store struct {
+	db   *sqlx.DB
+	repo repositories.IdempotencyRepository
+	ttl  time.Duration
+	// lockTimeout is how long a key stays in progress once its request stops refreshing the lock,
+	// e.g. because the process died, before a retry can take it over.
+	lockTimeout time.Duration
+}
+
+func NewStore(db *sqlx.DB, repo repositories.IdempotencyRepository, ttl, lockTimeout time.Duration) *Store {
+	return &Store{
+		db:          db,
+		repo:        repo,
+		ttl:         ttl,
+		lockTimeout: lockTimeout,
+	}
+}
+
+// Begin claims the key for a request with the given hash. When the key has already been
+// used for the same request, the stored response is returned and the request must not be
+// processed again.
+func (s *Store) Begin(ctx context.Context, scope, key, requestHash string) (*middlewares.StoredResponse, error) {
+	var stored *middlewares.StoredResponse
+
+	err := database.WithinTransaction(ctx, s.db, func(tx *sqlx.Tx) error {
+		reserved, err := s.repo.ReserveKey(ctx, tx, scope, key, requestHash, s.ttl, s.lockTimeout)
+		if err != nil || reserved {
+			return err
+		}
+
+		record, err := s.repo.GetKey(ctx, tx, scope, key)
+		if err != nil {
+			return err
+		}
+		if record.RequestHash != requestHash {
+			return middlewares.ErrIdempotencyKeyReused
+		}
+		stored, err = storedResponse(record)
+		return err
+	})
+
+	return stored, err
+}
+
+// Lookup returns the response stored under the key for a request with the given hash, or nil
+// when no response has been stored under the key yet. Unlike Begin, it doesn't claim the key.
+func (s *Store) Lookup(ctx context.Context, scope, key, requestHash string) (*middlewares.StoredResponse, error) {
+	var stored *middlewares.StoredResponse
+
+	err := database.WithinTransaction(ctx, s.db, func(tx *sqlx.Tx) error {
+		record, err := s.repo.GetKey(ctx, tx, scope, key)
+		if err != nil {
+			if apperrors.MapToAppError(err).Code == http.StatusNotFound {
+				return nil
+			}
+			return err
+		}
+		if record.ExpiresAt.Before(time.Now()) {
+			return nil
+		}
+		if record.RequestHash != requestHash {
+			return middlewares.ErrIdempotencyKeyReused
+		}
+
+		stored, err = storedResponse(record)
+		return err
+	})
+
+	return stored, err
+}
+
+// storedResponse returns the response recorded for the key, or ErrIdempotentRequestInProgress
+// when the request made under it hasn't completed.
+func storedResponse(record repositories.IdempotencyKey) (*middlewares.StoredResponse, error) {
+	if !record.StatusCode.Valid {
+		return nil, middlewares.ErrIdempotentRequestInProgress
+	}
+
+	stored := &middlewares.StoredResponse{
+		StatusCode: int(record.StatusCode.Int32),
+		Header:     http.Header{},
+		Body:       record.ResponseBody,
+	}
+	if len(record.ResponseHeaders) > 0 {
+		if err := json.Unmarshal(record.ResponseHeaders, &stored.Header); err != nil {
+			return nil, err
+		}
+	}
+	return stored, nil
+}
+
+// KeepLocked refreshes the lock of the key until stop is called, so a long request keeps it while
+// a dead one loses it after the lock timeout.
+func (s *Store) KeepLocked(ctx context.Context, scope, key string) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(s.lockTimeout / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			err := database.WithinTransaction(ctx, s.db, func(tx *sqlx.Tx) error {
+				return s.repo.RefreshKeyLock(ctx, tx, scope, key)
+			})
+			if logger := middlewares.GetLoggerFromContext(ctx); err != nil && ctx.Err() == nil && logger != nil {
+				logger.WithField("error", err).Error("failed to refresh idempotency key lock")
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// Complete stores the response of the request made under the key.
+func (s *Store) Complete(ctx context.Context, scope, key string, resp middlewares.StoredResponse) error {
+	headers, err := json.Marshal(resp.Header)
+	if err != nil {
+		return err
+	}
+
+	return database.WithinTransaction(ctx, s.db, func(tx *sqlx.Tx) error {
+		return s.repo.CompleteKey(ctx, tx, repositories.IdempotencyKey{
+			Scope:           scope,
+			Key:             key,
+			StatusCode:      sql.NullInt32{Int32: int32(resp.StatusCode), Valid: true},
+			ResponseHeaders: headers,
+			ResponseBody:    resp.Body,
+		})
+	})
+}
+
+// Save stores the response of a request that was made without claiming the key first. It's stored
+// in its own transaction, see SaveWithin.
+func (s *Store) Save(ctx context.Context, scope, key, requestHash string, resp middlewares.StoredResponse) error {
+	return database.WithinTransaction(ctx, s.db, func(tx *sqlx.Tx) error {
+		return s.SaveWithin(ctx, tx, scope, key, requestHash, resp)
+	})
+}
+
+// SaveWithin stores the response within tx, typically the transaction of the change the response
+// is about, so the response is stored if and only if the change is committed. It fails when the key
+// is already taken, which rolls the change back, as a server error so the request can be retried
+// and find the stored response.
+func (s *Store) SaveWithin(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	scope, key, requestHash string,
+	resp middlewares.StoredResponse,
+) error {
+	headers, err := json.Marshal(resp.Header)
+	if err != nil {
+		return err
+	}
+
+	reserved, err := s.repo.ReserveKey(ctx, tx, scope, key, requestHash, s.ttl, s.lockTimeout)
+	if err != nil {
+		return err
+	}
+	if !reserved {
+		return apperrors.NewInternalServerError("a response has already been stored under the key")
+	}
+
+	return s.repo.CompleteKey(ctx, tx, repositories.IdempotencyKey{
+		Scope:           scope,
+		Key:             key,
+		StatusCode:      sql.NullInt32{Int32: int32(resp.StatusCode), Valid: true},
+		ResponseHeaders: headers,
+		ResponseBody:    resp.Body,
+	})
+}
+
+// Release frees the key, so the request can be retried with it.
+func (s *Store) Release(ctx context.Context, scope, key string) error {
+	return database.WithinTransaction(ctx, s.db, func(tx *sqlx.Tx) error {
+		return s.repo.DeleteKey(ctx, tx, scope, key)
+	})
+}
@@ -0,0 +1,63 @@
+package outbox
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+type Config struct {
+	// RelayInProcess runs the relay inside the http and grpc services. Turn it off when
+	// the relay runs as a separate outbox-relay process.
+	RelayInProcess bool
+	PollInterval   time.Duration
+	BatchSize      int
+	MinBackoff     time.Duration
+	MaxBackoff     time.Duration
+	// Retention is how long published messages are kept.
+	Retention time.Duration
+	// MetricsAddr is where the standalone relay serves its metrics.
+	MetricsAddr string
+}
+
+func LoadOutboxConfig() *Config {
+	viper.SetDefault("outbox.relay_in_process", true)
+	viper.SetDefault("outbox.poll_interval", "500ms")
+	viper.SetDefault("outbox.batch_size", 100)
+	viper.SetDefault("outbox.min_backoff", "1s")
+	viper.SetDefault("outbox.max_backoff", "1m")
+	viper.SetDefault("outbox.retention", "24h")
+	viper.SetDefault("outbox.metrics_addr", ":8081")
+
+	return &Config{
+		RelayInProcess: viper.GetBool("outbox.relay_in_process"),
+		PollInterval:   viper.GetDuration("outbox.poll_interval"),
+		BatchSize:      viper.GetInt("outbox.batch_size"),
+		MinBackoff:     viper.GetDuration("outbox.min_backoff"),
+		MaxBackoff:     viper.GetDuration("outbox.max_backoff"),
+		Retention:      viper.GetDuration("outbox.retention"),
+		MetricsAddr:    viper.GetString("outbox.metrics_addr"),
+	}
+}
+
+func (c *Config) Validate() error {
+	var errs []error
+	if c.PollInterval <= 0 {
+		errs = append(errs, fmt.Errorf("outbox.poll_interval must be positive, got %s", c.PollInterval))
+	}
+	if c.BatchSize <= 0 {
+		errs = append(errs, fmt.Errorf("outbox.batch_size must be positive, got %d", c.BatchSize))
+	}
+	if c.MinBackoff <= 0 {
+		errs = append(errs, fmt.Errorf("outbox.min_backoff must be positive, got %s", c.MinBackoff))
+	}
+	if c.MaxBackoff < c.MinBackoff {
+		errs = append(errs, fmt.Errorf("outbox.max_backoff must be at least outbox.min_backoff, got %s", c.MaxBackoff))
+	}
+	if c.Retention <= 0 {
+		errs = append(errs, fmt.Errorf("outbox.retention must be positive, got %s", c.Retention))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,230 @@
+// Package outbox publishes the events stored in the outbox table to Kafka.
+package outbox
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/faeelol/companies-store/internal/app/database"
+	"github.com/faeelol/companies-store/internal/app/database/repositories"
+	"github.com/faeelol/companies-store/internal/app/metrics"
+	"github.com/faeelol/companies-store/internal/app/tracing"
+)
+
+const cleanupBatchSize = 1000
+
+var (
+	pendingMessages = metrics.NewGauge("outbox_pending_messages", "Messages waiting in the outbox.")
+	lagSeconds      = metrics.NewFloatGauge("outbox_lag_seconds", "Age of the oldest message waiting in the outbox.")
+	sentMessages    = metrics.NewCounter("outbox_sent_messages_total", "Messages published from the outbox.")
+	failedAttempts  = metrics.NewCounter("outbox_failed_attempts_total", "Failed attempts to publish the outbox.")
+)
+
+type Publisher interface {
+	Publish(ctx context.Context, key string, value []byte, headers map[string]string) error
+}
+
+// Relay publishes the outbox messages in the order they were added. Only one relay publishes
+// at a time, the others wait for its lock. A message that fails to publish holds back the
+// following ones until it's published, so the order is kept. Messages are delivered at least
+// once: a crash between the publish and marking the message as sent publishes it again.
+type Relay struct {
+	cfg       *Config
+	db        *sqlx.DB
+	repo      repositories.OutboxRepository
+	publisher Publisher
+}
+
+func NewRelay(cfg *Config, db *sqlx.DB, repo repositories.OutboxRepository, publisher Publisher) *Relay {
+	return &Relay{
+		cfg:       cfg,
+		db:        db,
+		repo:      repo,
+		publisher: publisher,
+	}
+}
+
+// Start runs the relay until ctx is done.
+func (r *Relay) Start(ctx context.Context, logger *logrus.Logger) error {
+	logger.Info("starting outbox relay")
+
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+
+	lastCleanup := time.Time{}
+	for {
+		if err := r.updateLag(ctx); err != nil && ctx.Err() == nil {
+			logger.WithField("error", err).Error("failed to get outbox lag")
+		}
+
+		sent, err := r.relayBatch(ctx, logger)
+		if err != nil && ctx.Err() == nil {
+			logger.WithField("error", err).Error("failed to relay outbox messages")
+		}
+
+		if time.Since(lastCleanup) > time.Minute {
+			if err := r.cleanup(ctx); err != nil && ctx.Err() == nil {
+				logger.WithField("error", err).Error("failed to clean up outbox")
+			}
+			lastCleanup = time.Now()
+		}
+
+		// a full batch means more messages are likely waiting
+		if sent == r.cfg.BatchSize {
+			if ctx.Err() != nil {
+				logger.Info("outbox relay stopped")
+				return nil
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			logger.Info("outbox relay stopped")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// relayBatch publishes a batch of pending messages and returns how many were published. The relay
+// lock is held on a connection of its own, so no transaction is open while the sinks are called;
+// the outcome is recorded in a short transaction afterwards.
+func (r *Relay) relayBatch(ctx context.Context, logger logrus.FieldLogger) (int, error) {
+	locked, unlock, err := r.lock(ctx, logger)
+	if err != nil || !locked {
+		return 0, err
+	}
+	defer unlock()
+
+	var messages []repositories.OutboxMessage
+	err = database.WithinTransaction(ctx, r.db, func(tx *sqlx.Tx) error {
+		var err error
+		messages, err = r.repo.ListPending(ctx, tx, r.cfg.BatchSize)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var sentIDs []int64
+	var failed *repositories.OutboxMessage
+	var publishErr error
+	for _, message := range messages {
+		if message.NextAttemptAt.After(time.Now()) {
+			break
+		}
+		if publishErr = r.publish(ctx, message); publishErr != nil {
+			failed = &message
+			break
+		}
+		sentIDs = append(sentIDs, message.ID)
+	}
+
+	err = database.WithinTransaction(ctx, r.db, func(tx *sqlx.Tx) error {
+		if err := r.repo.MarkSent(ctx, tx, sentIDs); err != nil {
+			return err
+		}
+		if failed == nil {
+			return nil
+		}
+
+		failedAttempts.Add(1)
+		logger.WithFields(logrus.Fields{
+			"error":      publishErr,
+			"message_id": failed.ID,
+			"attempts":   failed.Attempts + 1,
+		}).Warn("failed to publish outbox message")
+
+		nextAttemptAt := time.Now().Add(r.backoff(failed.Attempts))
+		return r.repo.MarkFailed(ctx, tx, failed.ID, nextAttemptAt, publishErr.Error())
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	sentMessages.Add(int64(len(sentIDs)))
+	return len(sentIDs), nil
+}
+
+// lock takes the relay lock on a dedicated connection. unlock releases the lock and the connection;
+// a connection whose lock can't be released is closed, which releases the lock too.
+func (r *Relay) lock(ctx context.Context, logger logrus.FieldLogger) (locked bool, unlock func(), err error) {
+	conn, err := r.db.Connx(ctx)
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to get a connection: %w", err)
+	}
+
+	locked, err = r.repo.TryLockRelay(ctx, conn)
+	if err != nil || !locked {
+		_ = conn.Close()
+		return false, nil, err
+	}
+
+	return true, func() {
+		if err := r.repo.UnlockRelay(context.WithoutCancel(ctx), conn); err != nil {
+			logger.WithField("error", err).Warn("failed to unlock outbox relay, closing its connection")
+			// the pool discards a connection reported as bad
+			_ = conn.Raw(func(any) error {
+				return driver.ErrBadConn
+			})
+		}
+		_ = conn.Close()
+	}, nil
+}
+
+// publish publishes the message, continuing the trace of the change it's about.
+func (r *Relay) publish(ctx context.Context, message repositories.OutboxMessage) error {
+	ctx, span := tracing.StartSpan(tracing.Extract(ctx, message.Headers), "outbox.relay",
+		trace.WithAttributes(attribute.Int64("outbox.message_id", message.ID)))
+	err := r.publisher.Publish(ctx, message.Key, message.Payload, message.Headers)
+	tracing.End(span, err)
+	return err
+}
+
+// backoff doubles the delay with every failed attempt, up to MaxBackoff.
+func (r *Relay) backoff(attempts int) time.Duration {
+	delay := r.cfg.MinBackoff
+	for i := 0; i < attempts && delay < r.cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, r.cfg.MaxBackoff)
+}
+
+func (r *Relay) updateLag(ctx context.Context) error {
+	return database.WithinTransaction(ctx, r.db, func(tx *sqlx.Tx) error {
+		lag, err := r.repo.GetLag(ctx, tx)
+		if err != nil {
+			return err
+		}
+
+		pendingMessages.Set(int64(lag.Pending))
+		if lag.OldestCreated != nil {
+			lagSeconds.Set(time.Since(*lag.OldestCreated).Seconds())
+		} else {
+			lagSeconds.Set(0)
+		}
+		return nil
+	})
+}
+
+func (r *Relay) cleanup(ctx context.Context) error {
+	for {
+		var deleted int64
+		err := database.WithinTransaction(ctx, r.db, func(tx *sqlx.Tx) error {
+			var err error
+			deleted, err = r.repo.DeleteSent(ctx, tx, r.cfg.Retention, cleanupBatchSize)
+			return err
+		})
+		if err != nil || deleted < cleanupBatchSize {
+			return err
+		}
+	}
+}
@@ -0,0 +1,245 @@
+// Package republish streams the current state of the companies to Kafka, so downstream
+// consumers that lost their state can rebuild it.
+package republish
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+
+	"github.com/faeelol/companies-store/internal/app/database"
+	"github.com/faeelol/companies-store/internal/app/events"
+	"github.com/faeelol/companies-store/internal/app/kafka"
+	"github.com/faeelol/companies-store/internal/app/model"
+)
+
+const DefaultBatchSize = 500
+
+type CompanyScanner interface {
+	ScanCompanies(
+		ctx context.Context,
+		tx *sqlx.Tx,
+		filter model.SnapshotFilter,
+		afterID uuid.UUID,
+		limit int,
+	) ([]model.Company, error)
+}
+
+type BatchPublisher interface {
+	PublishBatch(ctx context.Context, msgs []kafka.Message) error
+}
+
+type Options struct {
+	Filter    model.SnapshotFilter
+	BatchSize int
+	// Rate limits the number of events published per second, 0 means no limit.
+	Rate int
+	// CheckpointPath is the file the progress is saved to after every batch. A run resumes
+	// from it when it exists. No checkpoint is kept when it's empty.
+	CheckpointPath string
+}
+
+// Checkpoint is the progress of a run: the id of the last published company, companies
+// are published in id order.
+type Checkpoint struct {
+	LastID    uuid.UUID `json:"last_id"`
+	Published int       `json:"published"`
+	SavedAt   time.Time `json:"saved_at"`
+}
+
+type Republisher struct {
+	db        *sqlx.DB
+	repo      CompanyScanner
+	events    *events.Builder
+	publisher BatchPublisher
+}
+
+func NewRepublisher(db *sqlx.DB, repo CompanyScanner, eventBuilder *events.Builder, publisher BatchPublisher) *Republisher {
+	return &Republisher{
+		db:        db,
+		repo:      repo,
+		events:    eventBuilder,
+		publisher: publisher,
+	}
+}
+
+// Run publishes a snapshot event for every company matching the filter and returns the number
+// of events published, the ones published by the resumed runs included.
+func (r *Republisher) Run(ctx context.Context, logger logrus.FieldLogger, opts Options) (int, error) {
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+	if opts.Rate > 0 && batchSize > opts.Rate {
+		// a batch is published at once, a smaller one spreads the events over the second
+		batchSize = opts.Rate
+	}
+
+	checkpoint, err := loadCheckpoint(opts.CheckpointPath)
+	if err != nil {
+		return 0, err
+	}
+	if checkpoint.LastID != uuid.Nil {
+		logger.WithFields(logrus.Fields{
+			"last_id":   checkpoint.LastID,
+			"published": checkpoint.Published,
+		}).Info("resuming from checkpoint")
+	}
+
+	throttle := newThrottle(opts.Rate)
+	for {
+		var companies []model.Company
+		err := database.WithinTransaction(ctx, r.db, func(tx *sqlx.Tx) error {
+			var err error
+			companies, err = r.repo.ScanCompanies(ctx, tx, opts.Filter, checkpoint.LastID, batchSize)
+			return err
+		})
+		if err != nil {
+			return checkpoint.Published, err
+		}
+		if len(companies) == 0 {
+			return checkpoint.Published, nil
+		}
+
+		if err := throttle.wait(ctx, len(companies)); err != nil {
+			return checkpoint.Published, err
+		}
+
+		msgs, err := r.snapshotMessages(companies)
+		if err != nil {
+			return checkpoint.Published, err
+		}
+		if err := r.publisher.PublishBatch(ctx, msgs); err != nil {
+			return checkpoint.Published, fmt.Errorf("failed to publish snapshots: %w", err)
+		}
+
+		checkpoint.LastID = companies[len(companies)-1].ID
+		checkpoint.Published += len(companies)
+		if err := saveCheckpoint(opts.CheckpointPath, checkpoint); err != nil {
+			return checkpoint.Published, err
+		}
+		logger.WithField("published", checkpoint.Published).Info("published company snapshots")
+
+		if len(companies) < batchSize {
+			return checkpoint.Published, nil
+		}
+	}
+}
+
+func (r *Republisher) snapshotMessages(companies []model.Company) ([]kafka.Message, error) {
+	msgs := make([]kafka.Message, 0, len(companies))
+	for i := range companies {
+		event, err := r.events.CompanyEvent(events.CompanySnapshot, nil, &companies[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to create event: %w", err)
+		}
+		value, err := json.Marshal(event)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize event: %w", err)
+		}
+
+		msgs = append(msgs, kafka.Message{
+			Key:   []byte(event.Subject),
+			Value: value,
+			Headers: map[string]string{
+				events.HeaderContentType: events.ContentType,
+				events.HeaderType:        string(events.CompanySnapshot),
+			},
+		})
+	}
+	return msgs, nil
+}
+
+func loadCheckpoint(path string) (Checkpoint, error) {
+	var checkpoint Checkpoint
+	if path == "" {
+		return checkpoint, nil
+	}
+
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return checkpoint, nil
+	}
+	if err != nil {
+		return checkpoint, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	if err := json.Unmarshal(raw, &checkpoint); err != nil {
+		return checkpoint, fmt.Errorf("failed to parse checkpoint %s: %w", path, err)
+	}
+	return checkpoint, nil
+}
+
+// saveCheckpoint replaces the checkpoint file atomically, so a crash never leaves it half written.
+func saveCheckpoint(path string, checkpoint Checkpoint) error {
+	if path == "" {
+		return nil
+	}
+
+	checkpoint.SavedAt = time.Now().UTC()
+	raw, err := json.Marshal(checkpoint)
+	if err != nil {
+		return fmt.Errorf("failed to serialize checkpoint: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	if _, err := tmp.Write(raw); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	return nil
+}
+
+// throttle spaces out batches so that no more than rate events are published per second on average.
+type throttle struct {
+	rate int
+	next time.Time
+}
+
+func newThrottle(rate int) *throttle {
+	return &throttle{rate: rate}
+}
+
+// wait blocks until the next n events can be published.
+func (t *throttle) wait(ctx context.Context, n int) error {
+	if t.rate <= 0 {
+		return nil
+	}
+
+	now := time.Now()
+	if t.next.Before(now) {
+		t.next = now
+	}
+	delay := t.next.Sub(now)
+	t.next = t.next.Add(time.Duration(n) * time.Second / time.Duration(t.rate))
+
+	if delay <= 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(delay):
+		return nil
+	}
+}
@@ -0,0 +1,187 @@
+// Package stream broadcasts the company events of this process to the connected stream clients.
+package stream
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/faeelol/companies-store/internal/app/events"
+	"github.com/faeelol/companies-store/internal/app/metrics"
+	"github.com/faeelol/companies-store/internal/app/model"
+)
+
+var ErrClosed = errors.New("event stream is closed")
+
+var subscribers = metrics.NewGauge("stream_subscribers", "Open event streams.")
+
+// Event is a company event as sent to the stream clients.
+type Event struct {
+	// ID is "<epoch>-<sequence>", the epoch tells the broker instances apart so an id issued
+	// by another process, or before a restart, isn't mistaken for one of ours.
+	ID          string
+	Type        events.Type
+	CompanyID   uuid.UUID
+	CompanyType model.CompanyType
+	// Data is the CloudEvent in the JSON event format.
+	Data []byte
+
+	seq uint64
+}
+
+// Subscription receives the events published after it was made. C is closed when the broker
+// closes, or when the subscriber falls too far behind; the client can then resume from the
+// last event it got.
+type Subscription struct {
+	C <-chan Event
+	c chan Event
+}
+
+// Broker keeps the recent events in a ring buffer, so clients can resume their stream after
+// a reconnection, and fans new events out to the subscriptions.
+type Broker struct {
+	cfg   *Config
+	epoch string
+
+	mu      sync.Mutex
+	buffer  []Event
+	next    int // index in buffer the next event goes to
+	lastSeq uint64
+	subs    map[*Subscription]struct{}
+	closed  bool
+}
+
+func NewBroker(cfg *Config) *Broker {
+	return &Broker{
+		cfg:    cfg,
+		epoch:  strconv.FormatInt(time.Now().UnixMilli(), 36),
+		buffer: make([]Event, 0, max(cfg.BufferSize, 1)),
+		subs:   map[*Subscription]struct{}{},
+	}
+}
+
+// Publish sends the event of a committed change of the company to the subscriptions.
+func (b *Broker) Publish(eventType events.Type, company model.Company, data []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+
+	b.lastSeq++
+	event := Event{
+		ID:          b.epoch + "-" + strconv.FormatUint(b.lastSeq, 10),
+		Type:        eventType,
+		CompanyID:   company.ID,
+		CompanyType: company.Type,
+		Data:        data,
+		seq:         b.lastSeq,
+	}
+
+	if len(b.buffer) < cap(b.buffer) {
+		b.buffer = append(b.buffer, event)
+	} else {
+		b.buffer[b.next] = event
+	}
+	b.next = (b.next + 1) % cap(b.buffer)
+
+	for sub := range b.subs {
+		select {
+		case sub.c <- event:
+		default:
+			// the client can't keep up, it resumes from the buffer after reconnecting
+			b.drop(sub)
+		}
+	}
+}
+
+// Subscribe subscribes to the events published from now on. When lastEventID is set, the buffered
+// events following it are returned to be sent first; resumed is false when they can't be, because
+// the id is unknown or already out of the buffer, and the client missed events.
+func (b *Broker) Subscribe(lastEventID string) (sub *Subscription, replay []Event, resumed bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, nil, false, ErrClosed
+	}
+
+	resumed = true
+	if lastEventID != "" {
+		replay, resumed = b.since(lastEventID)
+	}
+
+	c := make(chan Event, max(b.cfg.SubscriberBuffer, 1))
+	sub = &Subscription{C: c, c: c}
+	b.subs[sub] = struct{}{}
+	subscribers.Add(1)
+
+	return sub, replay, resumed, nil
+}
+
+// since returns the buffered events published after the one with the given id.
+func (b *Broker) since(lastEventID string) ([]Event, bool) {
+	epoch, rawSeq, ok := strings.Cut(lastEventID, "-")
+	if !ok || epoch != b.epoch {
+		return nil, false
+	}
+	seq, err := strconv.ParseUint(rawSeq, 10, 64)
+	if err != nil || seq > b.lastSeq {
+		return nil, false
+	}
+
+	var replay []Event
+	// the oldest buffered event is at next once the buffer is full
+	for i := range b.buffer {
+		event := b.buffer[(b.next+i)%len(b.buffer)]
+		if event.seq > seq {
+			replay = append(replay, event)
+		}
+	}
+
+	// the event following the last one seen has to be buffered, or there is a gap
+	if seq < b.lastSeq && (len(replay) == 0 || replay[0].seq != seq+1) {
+		return nil, false
+	}
+	return replay, true
+}
+
+// Unsubscribe stops the subscription.
+func (b *Broker) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.drop(sub)
+}
+
+func (b *Broker) drop(sub *Subscription) {
+	if _, ok := b.subs[sub]; !ok {
+		return
+	}
+	delete(b.subs, sub)
+	close(sub.c)
+	subscribers.Add(-1)
+}
+
+// Close ends all the subscriptions, so the streams finish before the server shuts down, and
+// returns how many there were.
+func (b *Broker) Close() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	closed := len(b.subs)
+	for sub := range b.subs {
+		b.drop(sub)
+	}
+	return closed
+}
+
+// Config returns the configuration of the streams.
+func (b *Broker) Config() *Config {
+	return b.cfg
+}
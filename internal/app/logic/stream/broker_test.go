@@ -0,0 +1,108 @@
+package stream
+
+import (
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/faeelol/companies-store/internal/app/events"
+	"github.com/faeelol/companies-store/internal/app/model"
+)
+
+func TestBrokerSince(t *testing.T) {
+	tests := []struct {
+		name        string
+		bufferSize  int
+		published   int
+		lastEventID func(epoch string) string
+		wantSeqs    []uint64
+		wantResumed bool
+	}{
+		{
+			name:        "resumes from the latest event",
+			bufferSize:  3,
+			published:   2,
+			lastEventID: func(epoch string) string { return epoch + "-2" },
+			wantResumed: true,
+		},
+		{
+			name:        "replays the events after the last one seen",
+			bufferSize:  3,
+			published:   2,
+			lastEventID: func(epoch string) string { return epoch + "-1" },
+			wantSeqs:    []uint64{2},
+			wantResumed: true,
+		},
+		{
+			name:        "replays the buffer in order once it wrapped around",
+			bufferSize:  3,
+			published:   5,
+			lastEventID: func(epoch string) string { return epoch + "-2" },
+			wantSeqs:    []uint64{3, 4, 5},
+			wantResumed: true,
+		},
+		{
+			name:        "replays the tail of a wrapped buffer",
+			bufferSize:  3,
+			published:   7,
+			lastEventID: func(epoch string) string { return epoch + "-5" },
+			wantSeqs:    []uint64{6, 7},
+			wantResumed: true,
+		},
+		{
+			name:        "rejects an id evicted from the buffer",
+			bufferSize:  3,
+			published:   5,
+			lastEventID: func(epoch string) string { return epoch + "-1" },
+		},
+		{
+			name:        "rejects an id of another epoch",
+			bufferSize:  3,
+			published:   2,
+			lastEventID: func(string) string { return "other-1" },
+		},
+		{
+			name:        "rejects an id beyond the last event",
+			bufferSize:  3,
+			published:   2,
+			lastEventID: func(epoch string) string { return epoch + "-3" },
+		},
+		{
+			name:        "rejects an id without a sequence",
+			bufferSize:  3,
+			published:   2,
+			lastEventID: func(epoch string) string { return epoch },
+		},
+		{
+			name:        "rejects a sequence that isn't a number",
+			bufferSize:  3,
+			published:   2,
+			lastEventID: func(epoch string) string { return epoch + "-x" },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBroker(&Config{BufferSize: tt.bufferSize, SubscriberBuffer: 1})
+			for range tt.published {
+				b.Publish(events.CompanyUpdated, model.Company{}, nil)
+			}
+
+			replay, resumed := b.since(tt.lastEventID(b.epoch))
+			if resumed != tt.wantResumed {
+				t.Fatalf("resumed = %v, want %v", resumed, tt.wantResumed)
+			}
+
+			var seqs []uint64
+			for _, event := range replay {
+				if want := b.epoch + "-" + strconv.FormatUint(event.seq, 10); event.ID != want {
+					t.Errorf("event id = %q, want %q", event.ID, want)
+				}
+				seqs = append(seqs, event.seq)
+			}
+			if !slices.Equal(seqs, tt.wantSeqs) {
+				t.Errorf("replayed sequences = %v, want %v", seqs, tt.wantSeqs)
+			}
+		})
+	}
+}
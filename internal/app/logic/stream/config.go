@@ -0,0 +1,58 @@
+package stream
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+type Config struct {
+	// BufferSize is how many recent events are kept for the clients resuming a stream.
+	BufferSize int
+	// SubscriberBuffer is how many events can wait for a slow client before it's disconnected.
+	SubscriberBuffer int
+	// HeartbeatInterval is how often a comment is sent on an idle stream, so proxies keep it open.
+	HeartbeatInterval time.Duration
+	// WriteTimeout bounds every write to a stream, a client not reading is disconnected.
+	WriteTimeout time.Duration
+	// Retry is the reconnection delay advised to the clients.
+	Retry time.Duration
+}
+
+func LoadStreamConfig() *Config {
+	viper.SetDefault("stream.buffer_size", 1000)
+	viper.SetDefault("stream.subscriber_buffer", 64)
+	viper.SetDefault("stream.heartbeat_interval", "15s")
+	viper.SetDefault("stream.write_timeout", "10s")
+	viper.SetDefault("stream.retry", "3s")
+
+	return &Config{
+		BufferSize:        viper.GetInt("stream.buffer_size"),
+		SubscriberBuffer:  viper.GetInt("stream.subscriber_buffer"),
+		HeartbeatInterval: viper.GetDuration("stream.heartbeat_interval"),
+		WriteTimeout:      viper.GetDuration("stream.write_timeout"),
+		Retry:             viper.GetDuration("stream.retry"),
+	}
+}
+
+func (c *Config) Validate() error {
+	var errs []error
+	if c.BufferSize <= 0 {
+		errs = append(errs, fmt.Errorf("stream.buffer_size must be positive, got %d", c.BufferSize))
+	}
+	if c.SubscriberBuffer <= 0 {
+		errs = append(errs, fmt.Errorf("stream.subscriber_buffer must be positive, got %d", c.SubscriberBuffer))
+	}
+	if c.HeartbeatInterval <= 0 {
+		errs = append(errs, fmt.Errorf("stream.heartbeat_interval must be positive, got %s", c.HeartbeatInterval))
+	}
+	if c.WriteTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("stream.write_timeout must be positive, got %s", c.WriteTimeout))
+	}
+	if c.Retry <= 0 {
+		errs = append(errs, fmt.Errorf("stream.retry must be positive, got %s", c.Retry))
+	}
+	return errors.Join(errs...)
+}
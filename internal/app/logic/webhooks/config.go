@@ -0,0 +1,92 @@
+package webhooks
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+type Config struct {
+	// WorkerInProcess runs the delivery worker inside the http, grpc and consume services. Turn it
+	// off when the worker runs as a separate webhook-worker process.
+	WorkerInProcess bool
+	PollInterval    time.Duration
+	BatchSize       int
+	// Concurrency is how many deliveries of a batch are made at once.
+	Concurrency int
+	// Timeout bounds a delivery request, a slower endpoint fails the attempt.
+	Timeout    time.Duration
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// MaxAttempts is how many times a delivery is attempted before it's given up.
+	MaxAttempts int
+	// DisableAfter is how many deliveries in a row have to be given up for a subscription to be disabled.
+	// The failed attempts of a delivery that is still retried don't count.
+	DisableAfter int
+	// Retention is how long finished deliveries are kept in the delivery log.
+	Retention time.Duration
+	// MetricsAddr is where the standalone worker serves its metrics.
+	MetricsAddr string
+}
+
+func LoadWebhooksConfig() *Config {
+	viper.SetDefault("webhooks.worker_in_process", true)
+	viper.SetDefault("webhooks.poll_interval", "1s")
+	viper.SetDefault("webhooks.batch_size", 50)
+	viper.SetDefault("webhooks.concurrency", 10)
+	viper.SetDefault("webhooks.timeout", "10s")
+	viper.SetDefault("webhooks.min_backoff", "10s")
+	viper.SetDefault("webhooks.max_backoff", "1h")
+	viper.SetDefault("webhooks.max_attempts", 10)
+	viper.SetDefault("webhooks.disable_after", 20)
+	viper.SetDefault("webhooks.retention", "168h")
+	viper.SetDefault("webhooks.metrics_addr", ":8082")
+
+	return &Config{
+		WorkerInProcess: viper.GetBool("webhooks.worker_in_process"),
+		PollInterval:    viper.GetDuration("webhooks.poll_interval"),
+		BatchSize:       viper.GetInt("webhooks.batch_size"),
+		Concurrency:     viper.GetInt("webhooks.concurrency"),
+		Timeout:         viper.GetDuration("webhooks.timeout"),
+		MinBackoff:      viper.GetDuration("webhooks.min_backoff"),
+		MaxBackoff:      viper.GetDuration("webhooks.max_backoff"),
+		MaxAttempts:     viper.GetInt("webhooks.max_attempts"),
+		DisableAfter:    viper.GetInt("webhooks.disable_after"),
+		Retention:       viper.GetDuration("webhooks.retention"),
+		MetricsAddr:     viper.GetString("webhooks.metrics_addr"),
+	}
+}
+
+func (c *Config) Validate() error {
+	var errs []error
+	if c.PollInterval <= 0 {
+		errs = append(errs, fmt.Errorf("webhooks.poll_interval must be positive, got %s", c.PollInterval))
+	}
+	if c.BatchSize <= 0 {
+		errs = append(errs, fmt.Errorf("webhooks.batch_size must be positive, got %d", c.BatchSize))
+	}
+	if c.Concurrency <= 0 {
+		errs = append(errs, fmt.Errorf("webhooks.concurrency must be positive, got %d", c.Concurrency))
+	}
+	if c.Timeout <= 0 {
+		errs = append(errs, fmt.Errorf("webhooks.timeout must be positive, got %s", c.Timeout))
+	}
+	if c.MinBackoff <= 0 {
+		errs = append(errs, fmt.Errorf("webhooks.min_backoff must be positive, got %s", c.MinBackoff))
+	}
+	if c.MaxBackoff < c.MinBackoff {
+		errs = append(errs, fmt.Errorf("webhooks.max_backoff must be at least webhooks.min_backoff, got %s", c.MaxBackoff))
+	}
+	if c.MaxAttempts <= 0 {
+		errs = append(errs, fmt.Errorf("webhooks.max_attempts must be positive, got %d", c.MaxAttempts))
+	}
+	if c.DisableAfter <= 0 {
+		errs = append(errs, fmt.Errorf("webhooks.disable_after must be positive, got %d", c.DisableAfter))
+	}
+	if c.Retention <= 0 {
+		errs = append(errs, fmt.Errorf("webhooks.retention must be positive, got %s", c.Retention))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,169 @@
+// Package webhooks manages the webhook subscriptions and delivers the company events to them.
+package webhooks
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+
+	"github.com/faeelol/companies-store/internal/app/apperrors"
+	"github.com/faeelol/companies-store/internal/app/database"
+	"github.com/faeelol/companies-store/internal/app/database/repositories"
+	"github.com/faeelol/companies-store/internal/app/model"
+)
+
+// secretPrefix marks the generated secrets, so they're easy to recognize.
+const secretPrefix = "whsec_"
+
+type Controller struct {
+	db   *sqlx.DB
+	repo repositories.WebhookRepository
+}
+
+func NewWebhooksController(db *sqlx.DB, repo repositories.WebhookRepository) *Controller {
+	return &Controller{
+		db:   db,
+		repo: repo,
+	}
+}
+
+// CreateSubscription creates a subscription, generating its secret when none is given.
+// The secret is only returned here.
+func (c *Controller) CreateSubscription(ctx context.Context, data model.CreateWebhookData) (model.WebhookSubscription, error) {
+	id, err := uuid.NewV7()
+	if err != nil {
+		return model.WebhookSubscription{}, apperrors.NewInternalServerError("failed to generate webhook subscription id").WithCause(err)
+	}
+
+	secret := data.Secret
+	if secret == "" {
+		if secret, err = generateSecret(); err != nil {
+			return model.WebhookSubscription{}, err
+		}
+	}
+
+	eventTypes := data.EventTypes
+	if eventTypes == nil {
+		// stored as an empty array rather than NULL
+		eventTypes = []string{}
+	}
+
+	var created model.WebhookSubscription
+	err = database.WithinTransaction(ctx, c.db, func(tx *sqlx.Tx) error {
+		var txErr error
+		created, txErr = c.repo.CreateSubscription(ctx, tx, &repositories.WebhookSubscription{
+			ID:          id,
+			URL:         data.URL,
+			EventTypes:  eventTypes,
+			Secret:      secret,
+			Description: data.Description,
+		})
+		return txErr
+	})
+	if err != nil {
+		return model.WebhookSubscription{}, err
+	}
+
+	created.Secret = secret
+	return created, nil
+}
+
+func (c *Controller) GetSubscription(ctx context.Context, id uuid.UUID) (model.WebhookSubscription, error) {
+	subscription := model.WebhookSubscription{}
+
+	err := database.WithinTransaction(ctx, c.db, func(tx *sqlx.Tx) error {
+		var txErr error
+		subscription, txErr = c.repo.GetSubscription(ctx, tx, id)
+		return txErr
+	})
+
+	return subscription, err
+}
+
+func (c *Controller) ListSubscriptions(ctx context.Context, params model.ListWebhooksParams) (model.WebhookSubscriptionsPage, error) {
+	page := model.WebhookSubscriptionsPage{}
+
+	err := database.WithinTransaction(ctx, c.db, func(tx *sqlx.Tx) error {
+		var txErr error
+		page, txErr = c.repo.ListSubscriptions(ctx, tx, params)
+		return txErr
+	})
+
+	return page, err
+}
+
+// UpdateSubscription applies the updates to the subscription. Enabling a subscription again
+// resets its failure count.
+func (c *Controller) UpdateSubscription(
+	ctx context.Context,
+	id uuid.UUID,
+	updates model.UpdateWebhookData,
+) (model.WebhookSubscription, error) {
+	var updated model.WebhookSubscription
+
+	err := database.WithinTransaction(ctx, c.db, func(tx *sqlx.Tx) error {
+		subscription, err := c.repo.GetSubscriptionForUpdate(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		if updates.URL != nil {
+			subscription.URL = *updates.URL
+		}
+		if updates.EventTypes != nil {
+			subscription.EventTypes = *updates.EventTypes
+		}
+		if updates.Secret != nil {
+			subscription.Secret = *updates.Secret
+		}
+		if updates.Description != nil {
+			subscription.Description = *updates.Description
+		}
+		if updates.Enabled != nil && *updates.Enabled != subscription.Enabled {
+			subscription.Enabled = *updates.Enabled
+			subscription.DisabledReason.Valid = false
+			subscription.ConsecutiveFailures = 0
+		}
+
+		updated, err = c.repo.UpdateSubscription(ctx, tx, &subscription)
+		return err
+	})
+
+	return updated, err
+}
+
+// DeleteSubscription removes the subscription, its pending deliveries are dropped.
+func (c *Controller) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
+	return database.WithinTransaction(ctx, c.db, func(tx *sqlx.Tx) error {
+		return c.repo.DeleteSubscription(ctx, tx, id)
+	})
+}
+
+// ListDeliveries returns the delivery log of a subscription, newest first.
+func (c *Controller) ListDeliveries(ctx context.Context, params model.ListWebhookDeliveriesParams) (model.WebhookDeliveriesPage, error) {
+	page := model.WebhookDeliveriesPage{}
+
+	err := database.WithinTransaction(ctx, c.db, func(tx *sqlx.Tx) error {
+		// an unknown subscription is reported rather than an empty log
+		if _, err := c.repo.GetSubscription(ctx, tx, params.SubscriptionID); err != nil {
+			return err
+		}
+
+		var txErr error
+		page, txErr = c.repo.ListDeliveries(ctx, tx, params)
+		return txErr
+	})
+
+	return page, err
+}
+
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", apperrors.NewInternalServerError("failed to generate webhook secret").WithCause(err)
+	}
+	return secretPrefix + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,312 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+
+	"github.com/faeelol/companies-store/internal/app/database"
+	"github.com/faeelol/companies-store/internal/app/database/repositories"
+	"github.com/faeelol/companies-store/internal/app/events"
+	"github.com/faeelol/companies-store/internal/app/metrics"
+)
+
+// Headers of a delivery request.
+const (
+	HeaderDeliveryID = "X-Webhook-Id"
+	HeaderEventType  = "X-Webhook-Event"
+	HeaderTimestamp  = "X-Webhook-Timestamp"
+	// HeaderSignature carries "sha256=" and the hex HMAC-SHA256 of "<timestamp>.<body>" keyed
+	// with the subscription secret.
+	HeaderSignature = "X-Webhook-Signature"
+
+	signaturePrefix = "sha256="
+	userAgent       = "companies-store-webhooks/1.0"
+)
+
+const (
+	cleanupBatchSize = 1000
+	maxErrorLength   = 1000
+)
+
+var (
+	succeededDeliveries = metrics.NewCounter("webhook_deliveries_succeeded_total",
+		"Webhook deliveries that succeeded.")
+	failedDeliveries = metrics.NewCounter("webhook_deliveries_failed_total",
+		"Webhook deliveries given up on.")
+	failedAttempts = metrics.NewCounter("webhook_failed_attempts_total",
+		"Failed webhook delivery attempts.")
+	disabledSubscriptions = metrics.NewCounter("webhook_subscriptions_disabled_total",
+		"Webhook subscriptions disabled for failing.")
+)
+
+// Worker posts the pending deliveries to the subscription endpoints. A failed delivery is retried
+// with exponential backoff until it succeeds or runs out of attempts, and a subscription failing
+// too many deliveries in a row is disabled. Several workers can run at once, each delivery is
+// attempted by one of them. Deliveries are made at least once and in no particular order.
+type Worker struct {
+	cfg    *Config
+	db     *sqlx.DB
+	repo   repositories.WebhookRepository
+	client *http.Client
+}
+
+type attemptResult struct {
+	// responseStatus is 0 when no response was received
+	responseStatus int
+	err            error
+}
+
+func NewWorker(cfg *Config, db *sqlx.DB, repo repositories.WebhookRepository) *Worker {
+	return &Worker{
+		cfg:  cfg,
+		db:   db,
+		repo: repo,
+		client: &http.Client{
+			Timeout: cfg.Timeout,
+			// a redirect is reported as a failure, the subscription URL should be fixed instead
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// Start runs the worker until ctx is done.
+func (w *Worker) Start(ctx context.Context, logger *logrus.Logger) error {
+	logger.Info("starting webhook worker")
+
+	ticker := time.NewTicker(w.cfg.PollInterval)
+	defer ticker.Stop()
+
+	lastCleanup := time.Time{}
+	for {
+		attempted, err := w.deliverBatch(ctx, logger)
+		if err != nil && ctx.Err() == nil {
+			logger.WithField("error", err).Error("failed to deliver webhooks")
+		}
+
+		if time.Since(lastCleanup) > time.Minute {
+			if err := w.cleanup(ctx); err != nil && ctx.Err() == nil {
+				logger.WithField("error", err).Error("failed to clean up webhook deliveries")
+			}
+			lastCleanup = time.Now()
+		}
+
+		// a full batch means more deliveries are likely due
+		if attempted == w.cfg.BatchSize {
+			if ctx.Err() != nil {
+				logger.Info("webhook worker stopped")
+				return nil
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			logger.Info("webhook worker stopped")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliverBatch attempts a batch of due deliveries and returns how many were attempted. The deliveries
+// are claimed in a transaction of their own, so no transaction is open while the endpoints are called,
+// and the outcome of each one is recorded in a short transaction afterwards.
+func (w *Worker) deliverBatch(ctx context.Context, logger logrus.FieldLogger) (int, error) {
+	var deliveries []repositories.DueWebhookDelivery
+	err := database.WithinTransaction(ctx, w.db, func(tx *sqlx.Tx) error {
+		var err error
+		deliveries, err = w.repo.ClaimDueDeliveries(ctx, tx, w.cfg.BatchSize, w.claimDuration())
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	results := make([]attemptResult, len(deliveries))
+	sem := make(chan struct{}, max(w.cfg.Concurrency, 1))
+	var wg sync.WaitGroup
+	for i, delivery := range deliveries {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			results[i] = w.deliver(ctx, delivery)
+			<-sem
+		}()
+	}
+	wg.Wait()
+
+	var errs []error
+	for i, delivery := range deliveries {
+		// the deliveries cut short by the shutdown are attempted again once their claim expires
+		if ctx.Err() != nil {
+			return len(deliveries), ctx.Err()
+		}
+		err := database.WithinTransaction(ctx, w.db, func(tx *sqlx.Tx) error {
+			return w.record(ctx, tx, logger, delivery, results[i])
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return len(deliveries), errors.Join(errs...)
+}
+
+// claimDuration is how long the deliveries of a batch stay claimed: long enough for every round of
+// Concurrency requests to time out, with one more timeout to spare for recording the outcomes.
+func (w *Worker) claimDuration() time.Duration {
+	concurrency := max(w.cfg.Concurrency, 1)
+	rounds := (w.cfg.BatchSize + concurrency - 1) / concurrency
+	return time.Duration(rounds+1) * w.cfg.Timeout
+}
+
+func (w *Worker) deliver(ctx context.Context, delivery repositories.DueWebhookDelivery) attemptResult {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return attemptResult{err: err}
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", events.ContentType)
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(HeaderDeliveryID, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(HeaderEventType, delivery.EventType)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, sign(delivery.Secret, timestamp, delivery.Payload))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return attemptResult{err: err}
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	// drain the body so the connection is reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return attemptResult{
+			responseStatus: resp.StatusCode,
+			err:            fmt.Errorf("endpoint responded with %s", resp.Status),
+		}
+	}
+	return attemptResult{responseStatus: resp.StatusCode}
+}
+
+// record stores the outcome of the delivery attempt and updates the failure count of the subscription.
+func (w *Worker) record(
+	ctx context.Context,
+	tx *sqlx.Tx,
+	logger logrus.FieldLogger,
+	delivery repositories.DueWebhookDelivery,
+	result attemptResult,
+) error {
+	if result.err == nil {
+		succeededDeliveries.Add(1)
+		if err := w.repo.MarkDeliverySucceeded(ctx, tx, delivery.ID, result.responseStatus); err != nil {
+			return err
+		}
+		return w.repo.RecordSubscriptionSuccess(ctx, tx, delivery.SubscriptionID)
+	}
+
+	failedAttempts.Add(1)
+	attempt := repositories.FailedWebhookAttempt{Error: truncate(result.err.Error(), maxErrorLength)}
+	if result.responseStatus != 0 {
+		attempt.ResponseStatus = &result.responseStatus
+	}
+	if delivery.Attempts+1 < w.cfg.MaxAttempts {
+		nextAttemptAt := time.Now().Add(w.backoff(delivery.Attempts))
+		attempt.NextAttemptAt = &nextAttemptAt
+	} else {
+		failedDeliveries.Add(1)
+	}
+
+	deliveryLogger := logger.WithFields(logrus.Fields{
+		"error":           result.err,
+		"delivery_id":     delivery.ID,
+		"subscription_id": delivery.SubscriptionID,
+		"attempts":        delivery.Attempts + 1,
+	})
+	if attempt.NextAttemptAt == nil {
+		deliveryLogger.Warn("webhook delivery failed, giving up")
+	} else {
+		deliveryLogger.Warn("webhook delivery failed")
+	}
+
+	if err := w.repo.MarkDeliveryFailed(ctx, tx, delivery.ID, attempt); err != nil {
+		return err
+	}
+	// only a delivery that is given up counts against the subscription, not every failed attempt
+	if attempt.NextAttemptAt != nil {
+		return nil
+	}
+
+	disabled, err := w.repo.RecordSubscriptionFailure(ctx, tx, delivery.SubscriptionID, w.cfg.DisableAfter)
+	if err != nil {
+		return err
+	}
+	if disabled {
+		disabledSubscriptions.Add(1)
+		logger.WithField("subscription_id", delivery.SubscriptionID).
+			Warnf("webhook subscription disabled after %d failed deliveries in a row", w.cfg.DisableAfter)
+	}
+	return nil
+}
+
+// backoff doubles the delay with every failed attempt, up to MaxBackoff.
+func (w *Worker) backoff(attempts int) time.Duration {
+	delay := w.cfg.MinBackoff
+	for i := 0; i < attempts && delay < w.cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, w.cfg.MaxBackoff)
+}
+
+func (w *Worker) cleanup(ctx context.Context) error {
+	for {
+		var deleted int64
+		err := database.WithinTransaction(ctx, w.db, func(tx *sqlx.Tx) error {
+			var err error
+			deleted, err = w.repo.DeleteFinishedDeliveries(ctx, tx, w.cfg.Retention, cleanupBatchSize)
+			return err
+		})
+		if err != nil || deleted < cleanupBatchSize {
+			return err
+		}
+	}
+}
+
+// sign computes the signature header value of a delivery, binding the body to the timestamp
+// so a captured request can't be replayed later with a fresh timestamp.
+func sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	// the cut may split a rune, which Postgres would reject
+	return strings.ToValidUTF8(s[:n], "")
+}
@@ -0,0 +1,19 @@
+package metrics
+
+import (
+	"github.com/spf13/viper"
+)
+
+type Config struct {
+	// Addr is the admin listener the http, grpc and consume services serve the metrics on,
+	// they're not served when it's empty.
+	Addr string
+}
+
+func LoadMetricsConfig() *Config {
+	viper.SetDefault("metrics.addr", ":9102")
+
+	return &Config{
+		Addr: viper.GetString("metrics.addr"),
+	}
+}
@@ -0,0 +1,204 @@
+// Package metrics exports the metrics of the service in the Prometheus text format.
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"expvar"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/faeelol/companies-store/internal/app/kafka"
+	"github.com/faeelol/companies-store/internal/app/model"
+)
+
+// countTimeout bounds the queries run on a scrape.
+const countTimeout = 5 * time.Second
+
+// Registry holds the metrics of the service.
+var Registry = prometheus.NewRegistry()
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		newKafkaCollector(),
+	)
+}
+
+// Handler serves the metrics of the registry. A failing collector doesn't fail the scrape,
+// the other metrics are still served.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{ErrorHandling: promhttp.ContinueOnError})
+}
+
+// RegisterDB exports the connection pool stats of the database.
+func RegisterDB(db *sql.DB, name string) {
+	registerOnce(collectors.NewDBStatsCollector(db, name))
+}
+
+// CompanyCounter counts the companies that aren't deleted by type.
+type CompanyCounter interface {
+	CountCompaniesByType(ctx context.Context) (map[model.CompanyType]int, error)
+}
+
+// RegisterCompanyCounter exports the number of companies by type, counted on every scrape.
+func RegisterCompanyCounter(counter CompanyCounter) {
+	registerOnce(&companiesCollector{
+		counter: counter,
+		desc: prometheus.NewDesc("companies", "Number of companies by type, the deleted ones excluded.",
+			[]string{"type"}, nil),
+	})
+}
+
+// NewCounter publishes an expvar counter on /debug/vars and exports it as the Prometheus counter
+// of the same name.
+func NewCounter(name, help string) *expvar.Int {
+	v := expvar.NewInt(name)
+	Registry.MustRegister(prometheus.NewCounterFunc(prometheus.CounterOpts{Name: name, Help: help}, func() float64 {
+		return float64(v.Value())
+	}))
+	return v
+}
+
+// NewGauge publishes an expvar gauge on /debug/vars and exports it as the Prometheus gauge of the
+// same name.
+func NewGauge(name, help string) *expvar.Int {
+	v := expvar.NewInt(name)
+	Registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{Name: name, Help: help}, func() float64 {
+		return float64(v.Value())
+	}))
+	return v
+}
+
+// NewFloatGauge is NewGauge for a float value.
+func NewFloatGauge(name, help string) *expvar.Float {
+	v := expvar.NewFloat(name)
+	Registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{Name: name, Help: help}, v.Value))
+	return v
+}
+
+// registerOnce registers the collector, unless an identical one already is.
+func registerOnce(c prometheus.Collector) {
+	if err := Registry.Register(c); err != nil {
+		var alreadyRegistered prometheus.AlreadyRegisteredError
+		if !errors.As(err, &alreadyRegistered) {
+			panic(err)
+		}
+	}
+}
+
+type companiesCollector struct {
+	counter CompanyCounter
+	desc    *prometheus.Desc
+}
+
+func (c *companiesCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *companiesCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), countTimeout)
+	defer cancel()
+
+	counts, err := c.counter.CountCompaniesByType(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.desc, err)
+		return
+	}
+	for _, companyType := range model.CompanyTypes {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(counts[companyType]), string(companyType))
+	}
+}
+
+// kafkaCollector exports the stats of the Kafka producers. The producers only report what happened
+// since their stats were last read, so the collector keeps the totals.
+type kafkaCollector struct {
+	published *prometheus.Desc
+	writes    *prometheus.Desc
+	messages  *prometheus.Desc
+	bytes     *prometheus.Desc
+	errors    *prometheus.Desc
+	retries   *prometheus.Desc
+	writeTime *prometheus.Desc
+
+	mu     sync.Mutex
+	totals map[string]*kafkaTotals // by topic
+}
+
+type kafkaTotals struct {
+	published  int64
+	failed     int64
+	writes     int64
+	messages   int64
+	bytes      int64
+	errors     int64
+	retries    int64
+	writeCount int64
+	writeTime  time.Duration
+}
+
+func newKafkaCollector() *kafkaCollector {
+	labels := []string{"topic"}
+	return &kafkaCollector{
+		published: prometheus.NewDesc("kafka_published_messages_total",
+			"Messages published to Kafka by outcome, success or failure.", []string{"topic", "result"}, nil),
+		writes:   prometheus.NewDesc("kafka_writer_writes_total", "Produce requests sent to Kafka.", labels, nil),
+		messages: prometheus.NewDesc("kafka_writer_messages_total", "Messages sent to Kafka, retries included.", labels, nil),
+		bytes:    prometheus.NewDesc("kafka_writer_bytes_total", "Bytes of the messages sent to Kafka.", labels, nil),
+		errors:   prometheus.NewDesc("kafka_writer_errors_total", "Produce requests that failed.", labels, nil),
+		retries:  prometheus.NewDesc("kafka_writer_retries_total", "Produce requests that were retries.", labels, nil),
+		writeTime: prometheus.NewDesc("kafka_writer_write_seconds", "Duration of the produce requests.",
+			labels, nil),
+		totals: map[string]*kafkaTotals{},
+	}
+}
+
+func (c *kafkaCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.published
+	ch <- c.writes
+	ch <- c.messages
+	ch <- c.bytes
+	ch <- c.errors
+	ch <- c.retries
+	ch <- c.writeTime
+}
+
+func (c *kafkaCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, stats := range kafka.Stats() {
+		totals, ok := c.totals[stats.Topic]
+		if !ok {
+			totals = &kafkaTotals{}
+			c.totals[stats.Topic] = totals
+		}
+		totals.published += stats.Published
+		totals.failed += stats.Failed
+		totals.writes += stats.Writer.Writes
+		totals.messages += stats.Writer.Messages
+		totals.bytes += stats.Writer.Bytes
+		totals.errors += stats.Writer.Errors
+		totals.retries += stats.Writer.Retries
+		totals.writeCount += stats.Writer.WriteTime.Count
+		totals.writeTime += stats.Writer.WriteTime.Sum
+	}
+
+	for topic, totals := range c.totals {
+		ch <- prometheus.MustNewConstMetric(c.published, prometheus.CounterValue, float64(totals.published), topic, "success")
+		ch <- prometheus.MustNewConstMetric(c.published, prometheus.CounterValue, float64(totals.failed), topic, "failure")
+		ch <- prometheus.MustNewConstMetric(c.writes, prometheus.CounterValue, float64(totals.writes), topic)
+		ch <- prometheus.MustNewConstMetric(c.messages, prometheus.CounterValue, float64(totals.messages), topic)
+		ch <- prometheus.MustNewConstMetric(c.bytes, prometheus.CounterValue, float64(totals.bytes), topic)
+		ch <- prometheus.MustNewConstMetric(c.errors, prometheus.CounterValue, float64(totals.errors), topic)
+		ch <- prometheus.MustNewConstMetric(c.retries, prometheus.CounterValue, float64(totals.retries), topic)
+		ch <- prometheus.MustNewConstSummary(c.writeTime, uint64(totals.writeCount), totals.writeTime.Seconds(), nil, topic)
+	}
+}
@@ -1,7 +1,13 @@
 // Package model defines the core data structures used across the application
 package model
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/faeelol/companies-store/internal/app/apperrors"
+)
 
 type CompanyType string
 
@@ -12,6 +18,17 @@ const (
 	SoleProprietorship CompanyType = "Sole Proprietorship"
 )
 
+// CompanyTypes are all the company types.
+var CompanyTypes = []CompanyType{Corporations, NonProfit, Cooperative, SoleProprietorship}
+
+func (t CompanyType) IsValid() bool {
+	switch t {
+	case Corporations, NonProfit, Cooperative, SoleProprietorship:
+		return true
+	}
+	return false
+}
+
 type Company struct {
 	ID             uuid.UUID   `json:"id"`
 	Name           string      `json:"name"`
@@ -19,15 +36,19 @@ type Company struct {
 	EmployeesCount int         `json:"employees_count"`
 	Registered     bool        `json:"registered"`
 	Type           CompanyType `json:"type"`
+	Version        int         `json:"version"`
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+	DeletedAt      *time.Time  `json:"deleted_at,omitempty"`
 }
 
 type CreateCompanyData struct {
-	ID             uuid.UUID
-	Name           string
-	Description    string
-	EmployeesCount int
-	Registered     bool
-	Type           CompanyType
+	ID             uuid.UUID   `json:"id"`
+	Name           string      `json:"name"`
+	Description    string      `json:"description,omitempty"`
+	EmployeesCount int         `json:"employees_count"`
+	Registered     bool        `json:"registered"`
+	Type           CompanyType `json:"type"`
 }
 
 type UpdateCompanyData struct {
@@ -37,4 +58,166 @@ type UpdateCompanyData struct {
 	EmployeesCount *int         `json:"employees_count,omitempty"`
 	Registered     *bool        `json:"registered,omitempty"`
 	Type           *CompanyType `json:"type,omitempty"`
+	// NewName renames the company looked up by Name. The REST API has no use for it, a name given
+	// with the id is the new name there.
+	NewName *string `json:"-"`
+}
+
+type SortOrder string
+
+const (
+	SortOrderAsc  SortOrder = "asc"
+	SortOrderDesc SortOrder = "desc"
+)
+
+// CompanySortField lists the columns a companies list can be ordered by.
+type CompanySortField string
+
+const (
+	SortByName           CompanySortField = "name"
+	SortByEmployeesCount CompanySortField = "employees_count"
+	SortByCreatedAt      CompanySortField = "created_at"
+	SortByUpdatedAt      CompanySortField = "updated_at"
+)
+
+func (f CompanySortField) IsValid() bool {
+	switch f {
+	case SortByName, SortByEmployeesCount, SortByCreatedAt, SortByUpdatedAt:
+		return true
+	}
+	return false
+}
+
+type ListCompaniesFilter struct {
+	Type              *CompanyType
+	Registered        *bool
+	MinEmployeesCount *int
+	MaxEmployeesCount *int
+}
+
+type ListCompaniesParams struct {
+	Filter    ListCompaniesFilter
+	SortBy    CompanySortField
+	SortOrder SortOrder
+	Limit     int
+	Cursor    string
+	WithTotal bool
+}
+
+// SnapshotFilter selects the companies to republish. UpdatedFrom is inclusive, UpdatedTo exclusive.
+type SnapshotFilter struct {
+	Type           *CompanyType
+	UpdatedFrom    *time.Time
+	UpdatedTo      *time.Time
+	IncludeDeleted bool
+}
+
+type CompaniesPage struct {
+	Companies  []Company `json:"companies"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+	Total      *int      `json:"total,omitempty"`
+}
+
+type SearchCompaniesParams struct {
+	Query  string
+	Limit  int
+	Cursor string
+}
+
+type CompanyHighlights struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+type CompanySearchHit struct {
+	Company    Company           `json:"company"`
+	Rank       float64           `json:"rank"`
+	Highlights CompanyHighlights `json:"highlights"`
+}
+
+type CompanySearchPage struct {
+	Results    []CompanySearchHit `json:"results"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+}
+
+// ConflictMode defines what a bulk import does with a company whose id or name already exists.
+type ConflictMode string
+
+const (
+	OnConflictSkip   ConflictMode = "skip"
+	OnConflictFail   ConflictMode = "fail"
+	OnConflictUpsert ConflictMode = "upsert"
+)
+
+func (m ConflictMode) IsValid() bool {
+	switch m {
+	case OnConflictSkip, OnConflictFail, OnConflictUpsert:
+		return true
+	}
+	return false
+}
+
+type BulkItemStatus string
+
+const (
+	BulkItemCreated BulkItemStatus = "created"
+	BulkItemUpdated BulkItemStatus = "updated"
+	BulkItemSkipped BulkItemStatus = "skipped"
+	BulkItemError   BulkItemStatus = "error"
+)
+
+type BulkCompanyItem struct {
+	Index   int
+	Company CreateCompanyData
+}
+
+type BulkItemResult struct {
+	Index  int                    `json:"index"`
+	ID     *uuid.UUID             `json:"id,omitempty"`
+	Status BulkItemStatus         `json:"status"`
+	Error  string                 `json:"error,omitempty"`
+	Code   string                 `json:"code,omitempty"`
+	Errors []apperrors.FieldError `json:"errors,omitempty"`
+}
+
+// SetError marks the item as failed with the given error.
+func (r *BulkItemResult) SetError(err error) {
+	appErr := apperrors.MapToAppError(err)
+	r.Status = BulkItemError
+	r.Error = appErr.Message
+	r.Code = appErr.ErrorCode
+	r.Errors = appErr.Details
+}
+
+type RevisionOperation string
+
+const (
+	RevisionCreate  RevisionOperation = "create"
+	RevisionUpdate  RevisionOperation = "update"
+	RevisionDelete  RevisionOperation = "delete"
+	RevisionRestore RevisionOperation = "restore"
+	RevisionPurge   RevisionOperation = "purge"
+)
+
+// CompanyRevision is a snapshot of a company taken right after a change.
+type CompanyRevision struct {
+	ID        int64             `json:"id"`
+	CompanyID uuid.UUID         `json:"company_id"`
+	Operation RevisionOperation `json:"operation"`
+	Version   int               `json:"version"`
+	Snapshot  Company           `json:"snapshot"`
+	Actor     string            `json:"actor,omitempty"`
+	RequestID string            `json:"request_id,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+type ListRevisionsParams struct {
+	CompanyID uuid.UUID
+	Limit     int
+	Cursor    string
+}
+
+type CompanyRevisionsPage struct {
+	Revisions  []CompanyRevision `json:"revisions"`
+	NextCursor string            `json:"next_cursor,omitempty"`
 }
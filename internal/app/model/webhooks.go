@@ -0,0 +1,95 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebhookSubscription is an endpoint the company events are posted to.
+type WebhookSubscription struct {
+	ID  uuid.UUID `json:"id"`
+	URL string    `json:"url"`
+	// EventTypes are the event types delivered to the endpoint, all of them when empty.
+	EventTypes  []string `json:"event_types"`
+	Description string   `json:"description,omitempty"`
+	// Secret signs the deliveries. It's only returned when the subscription is created.
+	Secret  string `json:"secret,omitempty"`
+	Enabled bool   `json:"enabled"`
+	// DisabledReason tells why the subscription was disabled automatically.
+	DisabledReason      string    `json:"disabled_reason,omitempty"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+type CreateWebhookData struct {
+	URL         string   `json:"url"`
+	EventTypes  []string `json:"event_types"`
+	Secret      string   `json:"secret"`
+	Description string   `json:"description"`
+}
+
+type UpdateWebhookData struct {
+	URL         *string   `json:"url"`
+	EventTypes  *[]string `json:"event_types"`
+	Secret      *string   `json:"secret"`
+	Description *string   `json:"description"`
+	Enabled     *bool     `json:"enabled"`
+}
+
+type ListWebhooksParams struct {
+	Limit  int
+	Cursor string
+}
+
+type WebhookSubscriptionsPage struct {
+	Subscriptions []WebhookSubscription `json:"subscriptions"`
+	NextCursor    string                `json:"next_cursor,omitempty"`
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	// WebhookDeliveryPending is a delivery not made yet, or failed and waiting to be retried.
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	// WebhookDeliveryFailed is a delivery given up after the last attempt failed.
+	WebhookDeliveryFailed WebhookDeliveryStatus = "failed"
+)
+
+func (s WebhookDeliveryStatus) IsValid() bool {
+	switch s {
+	case WebhookDeliveryPending, WebhookDeliverySucceeded, WebhookDeliveryFailed:
+		return true
+	}
+	return false
+}
+
+// WebhookDelivery is an event delivery to a subscription, with the outcome of its last attempt.
+type WebhookDelivery struct {
+	ID             int64                 `json:"id"`
+	SubscriptionID uuid.UUID             `json:"subscription_id"`
+	EventID        string                `json:"event_id"`
+	EventType      string                `json:"event_type"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       int                   `json:"attempts"`
+	ResponseStatus *int                  `json:"response_status,omitempty"`
+	LastError      string                `json:"last_error,omitempty"`
+	NextAttemptAt  *time.Time            `json:"next_attempt_at,omitempty"`
+	LastAttemptAt  *time.Time            `json:"last_attempt_at,omitempty"`
+	CreatedAt      time.Time             `json:"created_at"`
+	FinishedAt     *time.Time            `json:"finished_at,omitempty"`
+}
+
+type ListWebhookDeliveriesParams struct {
+	SubscriptionID uuid.UUID
+	Status         *WebhookDeliveryStatus
+	Limit          int
+	Cursor         string
+}
+
+type WebhookDeliveriesPage struct {
+	Deliveries []WebhookDelivery `json:"deliveries"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
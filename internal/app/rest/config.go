@@ -1,6 +1,8 @@
 package rest
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/spf13/viper"
@@ -14,7 +16,14 @@ type Config struct {
 	ReadTimeout       time.Duration
 	ReadHeaderTimeout time.Duration
 	IdleTimeout       time.Duration
-	JWT               *jwt.Config
+	IdempotencyTTL    time.Duration
+	// IdempotencyLockTimeout is how long a key stays in progress after the request holding it
+	// stopped, e.g. because the process was killed, before a retry can take it over.
+	IdempotencyLockTimeout time.Duration
+	// ShutdownTimeout bounds the wait for the in-flight requests on shutdown, the ones still
+	// running after it are aborted.
+	ShutdownTimeout time.Duration
+	JWT             *jwt.Config
 }
 
 func NewConfig() *Config {
@@ -29,13 +38,40 @@ func LoadServerConfig() *Config {
 	viper.SetDefault("server.read_timeout", "15s")
 	viper.SetDefault("server.read_header_timeout", "5s")
 	viper.SetDefault("server.idle_timeout", "60s")
+	viper.SetDefault("server.idempotency_ttl", "24h")
+	viper.SetDefault("server.idempotency_lock_timeout", "30s")
+	viper.SetDefault("server.shutdown_timeout", "15s")
 
 	return &Config{
-		Addr:              viper.GetString("server.addr"),
-		WriteTimeout:      viper.GetDuration("server.write_timeout"),
-		ReadTimeout:       viper.GetDuration("server.read_timeout"),
-		ReadHeaderTimeout: viper.GetDuration("server.read_header_timeout"),
-		IdleTimeout:       viper.GetDuration("server.idle_timeout"),
-		JWT:               jwt.LoadJWTConfig(),
+		Addr:                   viper.GetString("server.addr"),
+		WriteTimeout:           viper.GetDuration("server.write_timeout"),
+		ReadTimeout:            viper.GetDuration("server.read_timeout"),
+		ReadHeaderTimeout:      viper.GetDuration("server.read_header_timeout"),
+		IdleTimeout:            viper.GetDuration("server.idle_timeout"),
+		IdempotencyTTL:         viper.GetDuration("server.idempotency_ttl"),
+		IdempotencyLockTimeout: viper.GetDuration("server.idempotency_lock_timeout"),
+		ShutdownTimeout:        viper.GetDuration("server.shutdown_timeout"),
+		JWT:                    jwt.LoadJWTConfig(),
 	}
 }
+
+func (c *Config) Validate() error {
+	var errs []error
+	if c.Addr == "" {
+		errs = append(errs, errors.New("server.addr is required"))
+	}
+	if c.ReadHeaderTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("server.read_header_timeout must be positive, got %s", c.ReadHeaderTimeout))
+	}
+	if c.IdempotencyTTL <= 0 {
+		errs = append(errs, fmt.Errorf("server.idempotency_ttl must be positive, got %s", c.IdempotencyTTL))
+	}
+	if c.IdempotencyLockTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("server.idempotency_lock_timeout must be positive, got %s", c.IdempotencyLockTimeout))
+	}
+	if c.ShutdownTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("server.shutdown_timeout must be positive, got %s", c.ShutdownTimeout))
+	}
+	errs = append(errs, c.JWT.Validate())
+	return errors.Join(errs...)
+}
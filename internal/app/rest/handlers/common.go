@@ -14,10 +14,14 @@ import (
 	"github.com/xeipuuv/gojsonschema"
 
 	"github.com/faeelol/companies-store/internal/app/apperrors"
+	"github.com/faeelol/companies-store/internal/app/rest/problem"
 )
 
 const (
 	ContentTypeAppJSON = "application/json"
+
+	// APIBasePath is the prefix all API routes are mounted under.
+	APIBasePath = "/api/companies_repo/v1"
 )
 
 func RespondCodeAndJSON(rw http.ResponseWriter, statusCode int, respData interface{}, logger logrus.FieldLogger) {
@@ -77,37 +81,65 @@ func ParseRequestJSON(r *http.Request, schema *gojsonschema.Schema, dst any) err
 		_ = Body.Close()
 	}(r.Body)
 
+	return decodeJSON(schema, body, dst)
+}
+
+// decodeJSON validates the body against the schema and unmarshals it into dst.
+func decodeJSON(schema *gojsonschema.Schema, body []byte, dst any) error {
+	if err := validateJSON(schema, body); err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(body, dst); err != nil {
+		return apperrors.NewBadRequestError("failed to parse JSON body")
+	}
+
+	return nil
+}
+
+func validateJSON(schema *gojsonschema.Schema, body []byte) error {
 	result, err := schema.Validate(gojsonschema.NewBytesLoader(body))
 	if err != nil {
 		return apperrors.NewBadRequestError(fmt.Sprintf("failed to validate JSON schema: %v", err))
 	}
 
 	if !result.Valid() {
-		var validationErrors []string
-		for _, err := range result.Errors() {
-			validationErrors = append(validationErrors, err.String())
+		details := make([]apperrors.FieldError, 0, len(result.Errors()))
+		for _, resErr := range result.Errors() {
+			details = append(details, apperrors.FieldError{
+				Pointer: jsonPointer(resErr),
+				Message: resErr.Description(),
+			})
 		}
-		return &ValidationError{
-			Errors: validationErrors,
-		}
-	}
-
-	if err := json.Unmarshal(body, dst); err != nil {
-		return apperrors.NewBadRequestError("failed to parse JSON body")
+		return apperrors.NewValidationError("request body doesn't match the schema", details)
 	}
 
 	return nil
 }
 
-// ValidationError list of errors
-type ValidationError struct {
-	Errors []string
-}
+// jsonPointer converts the field path reported by gojsonschema ("(root)", "a.0.b") into a JSON pointer.
+// Errors about a missing property are reported on the object, so the property is appended.
+func jsonPointer(resErr gojsonschema.ResultError) string {
+	var tokens []string
+	if field := resErr.Field(); field != gojsonschema.STRING_ROOT_SCHEMA_PROPERTY {
+		tokens = strings.Split(field, ".")
+	}
+	if resErr.Type() == "required" {
+		if property, ok := resErr.Details()["property"].(string); ok {
+			tokens = append(tokens, property)
+		}
+	}
 
-func (e *ValidationError) Error() string {
-	return strings.Join(e.Errors, ";\n")
+	var pointer strings.Builder
+	for _, token := range tokens {
+		pointer.WriteString("/")
+		pointer.WriteString(pointerEscaper.Replace(token))
+	}
+	return pointer.String()
 }
 
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
 func mustJSONSchema(js []byte) *gojsonschema.Schema {
 	schema, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(js))
 	if err != nil {
@@ -123,5 +155,7 @@ func RespondError(w http.ResponseWriter, err error, logger logrus.FieldLogger) {
 		logger.WithField("error", err).WithField("cause", appErr.Err).Error("internal server error")
 	}
 
-	RespondCodeAndJSON(w, appErr.Code, map[string]string{"error": appErr.Message}, logger)
+	if err := problem.Write(w, appErr); err != nil && logger != nil {
+		logger.WithField("error", err).Error("error while writing error response")
+	}
 }
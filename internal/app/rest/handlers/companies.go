@@ -2,7 +2,11 @@ package handlers
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"github.com/xeipuuv/gojsonschema"
@@ -12,8 +16,15 @@ import (
 	"github.com/faeelol/companies-store/internal/app/rest/middlewares"
 )
 
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+
+	maxSearchQueryLength = 200
+)
+
 type CreateCompaniesController interface {
-	CreateCompany(ctx context.Context, company model.CreateCompanyData) error
+	CreateCompany(ctx context.Context, company model.CreateCompanyData) (model.Company, error)
 }
 
 type CreateCompaniesHandler struct {
@@ -21,9 +32,35 @@ type CreateCompaniesHandler struct {
 	ccr    CreateCompaniesController
 }
 
+var (
+	createCompanyJSONSchema = mustJSONSchema(createCompaniesSchema)
+	patchCompanyJSONSchema  = mustJSONSchema(patchCompaniesSchema)
+)
+
+// DecodeCreateCompany validates a company to create against the schema of the create endpoint.
+func DecodeCreateCompany(body []byte) (model.CreateCompanyData, error) {
+	var company CreateCompanyRequest
+	if err := decodeJSON(createCompanyJSONSchema, body, &company); err != nil {
+		return model.CreateCompanyData{}, err
+	}
+	return company.ToDTO(), nil
+}
+
+// DecodePatchCompany validates company updates against the schema of the patch endpoint.
+func DecodePatchCompany(body []byte) (model.UpdateCompanyData, error) {
+	var updates model.UpdateCompanyData
+	if err := decodeJSON(patchCompanyJSONSchema, body, &updates); err != nil {
+		return model.UpdateCompanyData{}, err
+	}
+	if err := checkUpdates(updates); err != nil {
+		return model.UpdateCompanyData{}, err
+	}
+	return updates, nil
+}
+
 func NewCreateCompaniesHandler(ccr CreateCompaniesController) *CreateCompaniesHandler {
 	return &CreateCompaniesHandler{
-		schema: mustJSONSchema(createCompaniesSchema),
+		schema: createCompanyJSONSchema,
 		ccr:    ccr,
 	}
 }
@@ -59,17 +96,26 @@ func (h *CreateCompaniesHandler) ServeHTTP(rw http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	err = h.ccr.CreateCompany(ctx, company.ToDTO())
+	created, err := h.ccr.CreateCompany(ctx, company.ToDTO())
 	if err != nil {
 		RespondError(rw, err, logger)
 		return
 	}
 
-	RespondCodeAndJSON(rw, http.StatusCreated, nil, nil)
+	rw.Header().Set("Location", companyLocation(created.ID))
+	rw.Header().Set("ETag", formatETag(created.Version))
+	RespondCodeAndJSON(rw, http.StatusCreated, created, logger)
+}
+
+// companyLocation is the URL the company can be fetched from.
+func companyLocation(id uuid.UUID) string {
+	return fmt.Sprintf("%s/companies?uuid=%s", APIBasePath, id)
 }
 
 type GetCompaniesController interface {
 	GetCompany(ctx context.Context, reqUUID uuid.UUID, name string) (model.Company, error)
+	ListCompanies(ctx context.Context, params model.ListCompaniesParams) (model.CompaniesPage, error)
+	GetCompanyAsOf(ctx context.Context, reqUUID uuid.UUID, asOf time.Time) (model.Company, error)
 }
 
 type GetCompaniesHandler struct {
@@ -95,10 +141,21 @@ func (h *GetCompaniesHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request)
 	id, err := getUUIDParam(r, "uuid", false)
 	if err != nil {
 		RespondError(rw, err, logger)
+		return
 	}
 
 	if name == "" && id == uuid.Nil {
-		RespondError(rw, apperrors.NewBadRequestError("name or uuid should be provided"), logger)
+		h.listCompanies(rw, r)
+		return
+	}
+
+	asOf, err := getTimeParam(r, "as_of", false)
+	if err != nil {
+		RespondError(rw, err, logger)
+		return
+	}
+	if asOf != nil {
+		h.getCompanyAsOf(rw, r, id, *asOf)
 		return
 	}
 
@@ -108,11 +165,156 @@ func (h *GetCompaniesHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	etag := formatETag(res.Version)
+	rw.Header().Set("ETag", etag)
+	if matchesIfNoneMatch(r, etag) {
+		RespondCodeAndJSON(rw, http.StatusNotModified, nil, nil)
+		return
+	}
+
+	RespondCodeAndJSON(rw, http.StatusOK, res, nil)
+}
+
+func (h *GetCompaniesHandler) getCompanyAsOf(rw http.ResponseWriter, r *http.Request, id uuid.UUID, asOf time.Time) {
+	logger := middlewares.GetLoggerFromContext(r.Context())
+
+	if id == uuid.Nil {
+		RespondError(rw, apperrors.NewBadRequestError("as_of can only be used together with uuid"), logger)
+		return
+	}
+
+	res, err := h.gcc.GetCompanyAsOf(r.Context(), id, asOf)
+	if err != nil {
+		RespondError(rw, err, logger)
+		return
+	}
+
+	RespondCodeAndJSON(rw, http.StatusOK, res, nil)
+}
+
+func (h *GetCompaniesHandler) listCompanies(rw http.ResponseWriter, r *http.Request) {
+	logger := middlewares.GetLoggerFromContext(r.Context())
+
+	params, err := parseListCompaniesParams(r)
+	if err != nil {
+		RespondError(rw, err, logger)
+		return
+	}
+
+	page, err := h.gcc.ListCompanies(r.Context(), params)
+	if err != nil {
+		RespondError(rw, err, logger)
+		return
+	}
+
+	RespondCodeAndJSON(rw, http.StatusOK, page, nil)
+}
+
+func parseListCompaniesParams(r *http.Request) (model.ListCompaniesParams, error) {
+	params := model.ListCompaniesParams{
+		SortBy:    model.SortByName,
+		SortOrder: model.SortOrderAsc,
+	}
+
+	if rawType, _ := getStringParam(r, "type", false); rawType != "" {
+		companyType := model.CompanyType(rawType)
+		if !companyType.IsValid() {
+			return params, apperrors.NewBadRequestError("invalid type param")
+		}
+		params.Filter.Type = &companyType
+	}
+
+	var err error
+	if params.Filter.Registered, err = getBoolParam(r, "registered", false); err != nil {
+		return params, err
+	}
+	if params.Filter.MinEmployeesCount, err = getIntParam(r, "min_employees_count", false); err != nil {
+		return params, err
+	}
+	if params.Filter.MaxEmployeesCount, err = getIntParam(r, "max_employees_count", false); err != nil {
+		return params, err
+	}
+
+	if sortBy, _ := getStringParam(r, "sort", false); sortBy != "" {
+		params.SortBy = model.CompanySortField(sortBy)
+		if !params.SortBy.IsValid() {
+			return params, apperrors.NewBadRequestError("invalid sort param")
+		}
+	}
+	if order, _ := getStringParam(r, "order", false); order != "" {
+		params.SortOrder = model.SortOrder(order)
+		if params.SortOrder != model.SortOrderAsc && params.SortOrder != model.SortOrderDesc {
+			return params, apperrors.NewBadRequestError("invalid order param")
+		}
+	}
+
+	if params.Limit, err = getLimitParam(r); err != nil {
+		return params, err
+	}
+
+	params.Cursor, _ = getStringParam(r, "cursor", false)
+
+	withTotal, err := getBoolParam(r, "with_total", false)
+	if err != nil {
+		return params, err
+	}
+	params.WithTotal = withTotal != nil && *withTotal
+
+	return params, nil
+}
+
+type SearchCompaniesController interface {
+	SearchCompanies(ctx context.Context, params model.SearchCompaniesParams) (model.CompanySearchPage, error)
+}
+
+type SearchCompaniesHandler struct {
+	scc SearchCompaniesController
+}
+
+func NewSearchCompaniesHandler(scc SearchCompaniesController) *SearchCompaniesHandler {
+	return &SearchCompaniesHandler{
+		scc: scc,
+	}
+}
+
+func (h *SearchCompaniesHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	logger := middlewares.GetLoggerFromContext(r.Context())
+	ctx := r.Context()
+
+	query, err := getStringParam(r, "q", true)
+	if err != nil {
+		RespondError(rw, err, logger)
+		return
+	}
+	query = strings.TrimSpace(query)
+	if query == "" || utf8.RuneCountInString(query) > maxSearchQueryLength {
+		RespondError(rw, apperrors.NewBadRequestError(
+			fmt.Sprintf("q param must contain between 1 and %d characters", maxSearchQueryLength)), logger)
+		return
+	}
+
+	params := model.SearchCompaniesParams{
+		Query: query,
+	}
+
+	if params.Limit, err = getLimitParam(r); err != nil {
+		RespondError(rw, err, logger)
+		return
+	}
+
+	params.Cursor, _ = getStringParam(r, "cursor", false)
+
+	res, err := h.scc.SearchCompanies(ctx, params)
+	if err != nil {
+		RespondError(rw, err, logger)
+		return
+	}
+
 	RespondCodeAndJSON(rw, http.StatusOK, res, nil)
 }
 
 type DeleteCompaniesController interface {
-	DeleteCompany(ctx context.Context, reqUUID uuid.UUID, name string) error
+	DeleteCompany(ctx context.Context, reqUUID uuid.UUID, name string, expectedVersions []int) error
 }
 
 type DeleteCompaniesHandler struct {
@@ -146,7 +348,13 @@ func (h *DeleteCompaniesHandler) ServeHTTP(rw http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	err = h.dcc.DeleteCompany(ctx, id, name)
+	expectedVersions, err := parseIfMatch(r)
+	if err != nil {
+		RespondError(rw, err, logger)
+		return
+	}
+
+	err = h.dcc.DeleteCompany(ctx, id, name, expectedVersions)
 	if err != nil {
 		RespondError(rw, err, logger)
 		return
@@ -155,8 +363,42 @@ func (h *DeleteCompaniesHandler) ServeHTTP(rw http.ResponseWriter, r *http.Reque
 	RespondCodeAndJSON(rw, http.StatusNoContent, nil, nil)
 }
 
+type RestoreCompaniesController interface {
+	RestoreCompany(ctx context.Context, reqUUID uuid.UUID) (model.Company, error)
+}
+
+type RestoreCompaniesHandler struct {
+	rcc RestoreCompaniesController
+}
+
+func NewRestoreCompaniesHandler(rcc RestoreCompaniesController) *RestoreCompaniesHandler {
+	return &RestoreCompaniesHandler{
+		rcc: rcc,
+	}
+}
+
+func (h *RestoreCompaniesHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	logger := middlewares.GetLoggerFromContext(r.Context())
+	ctx := r.Context()
+
+	id, err := getUUIDURLParam(r, "id")
+	if err != nil {
+		RespondError(rw, err, logger)
+		return
+	}
+
+	restored, err := h.rcc.RestoreCompany(ctx, id)
+	if err != nil {
+		RespondError(rw, err, logger)
+		return
+	}
+
+	rw.Header().Set("ETag", formatETag(restored.Version))
+	RespondCodeAndJSON(rw, http.StatusOK, restored, nil)
+}
+
 type PatchCompaniesController interface {
-	UpdateCompany(ctx context.Context, updates model.UpdateCompanyData) error
+	UpdateCompany(ctx context.Context, updates model.UpdateCompanyData, expectedVersions []int) (model.Company, error)
 }
 
 type PatchCompaniesHandler struct {
@@ -167,7 +409,7 @@ type PatchCompaniesHandler struct {
 func NewPatchCompaniesHandler(pcc PatchCompaniesController) *PatchCompaniesHandler {
 	return &PatchCompaniesHandler{
 		pcc:    pcc,
-		schema: mustJSONSchema(patchCompaniesSchema),
+		schema: patchCompanyJSONSchema,
 	}
 }
 
@@ -208,16 +450,32 @@ func (h *PatchCompaniesHandler) ServeHTTP(rw http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	if updates.Name == nil && updates.Description == nil && updates.EmployeesCount == nil && updates.Registered == nil && updates.Type == nil {
-		RespondError(rw, apperrors.NewBadRequestError("no fields to update"), logger)
+	if err := checkUpdates(updates); err != nil {
+		RespondError(rw, err, logger)
 		return
 	}
 
-	err = h.pcc.UpdateCompany(ctx, updates)
+	expectedVersions, err := parseIfMatch(r)
 	if err != nil {
 		RespondError(rw, err, logger)
 		return
 	}
 
+	updated, err := h.pcc.UpdateCompany(ctx, updates, expectedVersions)
+	if err != nil {
+		RespondError(rw, err, logger)
+		return
+	}
+
+	rw.Header().Set("ETag", formatETag(updated.Version))
 	RespondCodeAndJSON(rw, http.StatusOK, nil, nil)
 }
+
+func checkUpdates(updates model.UpdateCompanyData) error {
+	// without id the name identifies the company, with id it is a new name
+	renames := updates.ID != nil && updates.Name != nil
+	if !renames && updates.Description == nil && updates.EmployeesCount == nil && updates.Registered == nil && updates.Type == nil {
+		return apperrors.NewBadRequestError("no fields to update")
+	}
+	return nil
+}
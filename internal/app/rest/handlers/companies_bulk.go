@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+
+	"github.com/xeipuuv/gojsonschema"
+
+	"github.com/faeelol/companies-store/internal/app/apperrors"
+	"github.com/faeelol/companies-store/internal/app/model"
+	"github.com/faeelol/companies-store/internal/app/rest/middlewares"
+)
+
+const (
+	ContentTypeNDJSON = "application/x-ndjson"
+
+	maxBulkItems    = 10000
+	maxBulkBodySize = 32 << 20
+	maxNDJSONLine   = 1 << 20
+)
+
+type BulkCreateCompaniesController interface {
+	BulkUpsertCompanies(ctx context.Context, items []model.BulkCompanyItem, mode model.ConflictMode) []model.BulkItemResult
+}
+
+type BulkCreateCompaniesHandler struct {
+	schema *gojsonschema.Schema
+	bcc    BulkCreateCompaniesController
+}
+
+func NewBulkCreateCompaniesHandler(bcc BulkCreateCompaniesController) *BulkCreateCompaniesHandler {
+	return &BulkCreateCompaniesHandler{
+		schema: createCompanyJSONSchema,
+		bcc:    bcc,
+	}
+}
+
+type BulkSummary struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+	Skipped int `json:"skipped"`
+	Failed  int `json:"failed"`
+}
+
+type BulkCreateCompaniesResponse struct {
+	Results []model.BulkItemResult `json:"results"`
+	Summary BulkSummary            `json:"summary"`
+}
+
+func (h *BulkCreateCompaniesHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	logger := middlewares.GetLoggerFromContext(r.Context())
+	ctx := r.Context()
+
+	mode := model.OnConflictFail
+	if rawMode, _ := getStringParam(r, "on_conflict", false); rawMode != "" {
+		mode = model.ConflictMode(rawMode)
+		if !mode.IsValid() {
+			RespondError(rw, apperrors.NewBadRequestError("on_conflict param must be one of skip, fail, upsert"), logger)
+			return
+		}
+	}
+
+	r.Body = http.MaxBytesReader(rw, r.Body, maxBulkBodySize)
+	rawItems, err := readBulkItems(r)
+	if err != nil {
+		RespondError(rw, err, logger)
+		return
+	}
+
+	var results []model.BulkItemResult
+	var items []model.BulkCompanyItem
+	for i, raw := range rawItems {
+		company, err := h.parseItem(raw)
+		if err != nil {
+			result := model.BulkItemResult{Index: i}
+			result.SetError(err)
+			results = append(results, result)
+			continue
+		}
+		items = append(items, model.BulkCompanyItem{Index: i, Company: company.ToDTO()})
+	}
+
+	results = append(results, h.bcc.BulkUpsertCompanies(ctx, items, mode)...)
+	sort.Slice(results, func(i, j int) bool { return results[i].Index < results[j].Index })
+
+	resp := BulkCreateCompaniesResponse{Results: results}
+	for _, res := range results {
+		switch res.Status {
+		case model.BulkItemCreated:
+			resp.Summary.Created++
+		case model.BulkItemUpdated:
+			resp.Summary.Updated++
+		case model.BulkItemSkipped:
+			resp.Summary.Skipped++
+		case model.BulkItemError:
+			resp.Summary.Failed++
+		}
+	}
+
+	RespondCodeAndJSON(rw, http.StatusOK, resp, logger)
+}
+
+func (h *BulkCreateCompaniesHandler) parseItem(raw json.RawMessage) (CreateCompanyRequest, error) {
+	var company CreateCompanyRequest
+	if !json.Valid(raw) {
+		return company, apperrors.NewBadRequestError("invalid JSON")
+	}
+	if err := validateJSON(h.schema, raw); err != nil {
+		return company, err
+	}
+	if err := json.Unmarshal(raw, &company); err != nil {
+		return company, apperrors.NewBadRequestError("failed to parse JSON item")
+	}
+	return company, nil
+}
+
+// readBulkItems splits the request body into raw items. A JSON array and a stream of
+// newline-delimited JSON objects are accepted.
+func readBulkItems(r *http.Request) ([]json.RawMessage, error) {
+	contentType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, apperrors.NewBadRequestError("Content-Type header is not set or invalid")
+	}
+	defer func(Body io.ReadCloser) {
+		_ = Body.Close()
+	}(r.Body)
+
+	var items []json.RawMessage
+	switch contentType {
+	case ContentTypeAppJSON:
+		items, err = readJSONArray(r.Body)
+	case ContentTypeNDJSON:
+		items, err = readNDJSON(r.Body)
+	default:
+		return nil, apperrors.NewBadRequestError(
+			fmt.Sprintf("Content-Type header must be %s or %s", ContentTypeAppJSON, ContentTypeNDJSON))
+	}
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, apperrors.NewBadRequestError("request body is too large")
+		}
+		return nil, err
+	}
+
+	if len(items) == 0 {
+		return nil, apperrors.NewBadRequestError("no items provided")
+	}
+
+	return items, nil
+}
+
+func readJSONArray(body io.Reader) ([]json.RawMessage, error) {
+	dec := json.NewDecoder(body)
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, wrapBulkReadError(err, "failed to parse JSON body")
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return nil, apperrors.NewBadRequestError("request body must be a JSON array")
+	}
+
+	var items []json.RawMessage
+	for dec.More() {
+		if len(items) == maxBulkItems {
+			return nil, apperrors.NewBadRequestError(fmt.Sprintf("too many items, at most %d allowed", maxBulkItems))
+		}
+		var item json.RawMessage
+		if err := dec.Decode(&item); err != nil {
+			return nil, wrapBulkReadError(err, "failed to parse JSON body")
+		}
+		items = append(items, item)
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return nil, wrapBulkReadError(err, "failed to parse JSON body")
+	}
+
+	return items, nil
+}
+
+func readNDJSON(body io.Reader) ([]json.RawMessage, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxNDJSONLine)
+
+	var items []json.RawMessage
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if len(items) == maxBulkItems {
+			return nil, apperrors.NewBadRequestError(fmt.Sprintf("too many items, at most %d allowed", maxBulkItems))
+		}
+		items = append(items, json.RawMessage(bytes.Clone(line)))
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, wrapBulkReadError(err, "failed to read NDJSON body")
+	}
+
+	return items, nil
+}
+
+func wrapBulkReadError(err error, message string) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return err
+	}
+	return apperrors.NewBadRequestError(message)
+}
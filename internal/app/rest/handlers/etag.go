@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/faeelol/companies-store/internal/app/apperrors"
+)
+
+// The company version is used as a strong entity tag, e.g. ETag: "3".
+
+func formatETag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// parseIfMatch returns the versions accepted by the If-Match header, or nil when the header is
+// absent or "*". Weak tags and tags that are not a known version can never match, so a header made
+// only of those fails the precondition.
+func parseIfMatch(r *http.Request) ([]int, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return nil, nil
+	}
+
+	var versions []int
+	weakOnly := true
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		weak := strings.HasPrefix(tag, "W/")
+
+		raw, err := strconv.Unquote(strings.TrimPrefix(tag, "W/"))
+		if err != nil {
+			return nil, apperrors.NewBadRequestError("invalid If-Match header")
+		}
+		if weak {
+			continue
+		}
+		weakOnly = false
+
+		if version, err := strconv.Atoi(raw); err == nil {
+			versions = append(versions, version)
+		}
+	}
+
+	if weakOnly {
+		return nil, apperrors.NewPreconditionFailedError("weak entity tags can't be used in If-Match")
+	}
+	if len(versions) == 0 {
+		return nil, apperrors.NewPreconditionFailedError("company has been modified, version mismatch").
+			WithErrorCode(apperrors.CodeVersionMismatch)
+	}
+
+	return versions, nil
+}
+
+// matchesIfNoneMatch reports whether the If-None-Match header matches the given entity tag,
+// using the weak comparison required for GET requests.
+func matchesIfNoneMatch(r *http.Request, etag string) bool {
+	header := strings.TrimSpace(r.Header.Get("If-None-Match"))
+	if header == "" {
+		return false
+	}
+	if header == "*" {
+		return true
+	}
+
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == etag {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/faeelol/companies-store/internal/app/apperrors"
+	"github.com/faeelol/companies-store/internal/app/events"
+	"github.com/faeelol/companies-store/internal/app/rest/middlewares"
+)
+
+const ContentTypeSchemaJSON = "application/schema+json"
+
+type EventSchemaInfo struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+type EventSchemasList struct {
+	Schemas []EventSchemaInfo `json:"schemas"`
+}
+
+type ListEventSchemasHandler struct{}
+
+func NewListEventSchemasHandler() *ListEventSchemasHandler {
+	return &ListEventSchemasHandler{}
+}
+
+func (h *ListEventSchemasHandler) ServeHTTP(rw http.ResponseWriter, _ *http.Request) {
+	res := EventSchemasList{Schemas: make([]EventSchemaInfo, 0, len(events.Types))}
+	for _, t := range events.Types {
+		res.Schemas = append(res.Schemas, EventSchemaInfo{
+			Type: string(t),
+			URL:  APIBasePath + "/events/schemas/" + t.SchemaName(),
+		})
+	}
+
+	RespondCodeAndJSON(rw, http.StatusOK, res, nil)
+}
+
+type GetEventSchemaHandler struct{}
+
+func NewGetEventSchemaHandler() *GetEventSchemaHandler {
+	return &GetEventSchemaHandler{}
+}
+
+func (h *GetEventSchemaHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	logger := middlewares.GetLoggerFromContext(r.Context())
+
+	schema, ok := events.Schema(chi.URLParam(r, "name"))
+	if !ok {
+		RespondError(rw, apperrors.NewNotFoundError("event schema not found"), logger)
+		return
+	}
+
+	rw.Header().Set("Content-Type", ContentTypeSchemaJSON)
+	rw.WriteHeader(http.StatusOK)
+	if _, err := rw.Write(schema); err != nil {
+		logger.WithField("error", err).Error("error while writing response body")
+	}
+}
@@ -0,0 +1,569 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/faeelol/companies-store/internal/app/rest/openapi"
+)
+
+const (
+	tagCompanies = "companies"
+	tagEvents    = "events"
+	tagWebhooks  = "webhooks"
+
+	uuidSchema   = `{"type": "string", "format": "uuid"}`
+	stringSchema = `{"type": "string"}`
+	boolSchema   = `{"type": "boolean"}`
+	intSchema    = `{"type": "integer"}`
+)
+
+var companySchema = []byte(`{
+  "type": "object",
+  "properties": {
+    "id": {"type": "string", "format": "uuid"},
+    "name": {"type": "string"},
+    "description": {"type": "string"},
+    "employees_count": {"type": "integer"},
+    "registered": {"type": "boolean"},
+    "type": {"type": "string", "enum": ["Corporations", "NonProfit", "Cooperative", "Sole Proprietorship"]},
+    "version": {"type": "integer", "description": "Incremented on every change, returned as the ETag"},
+    "created_at": {"type": "string", "format": "date-time"},
+    "updated_at": {"type": "string", "format": "date-time"},
+    "deleted_at": {"type": "string", "format": "date-time"}
+  },
+  "required": ["id", "name", "employees_count", "registered", "type", "version", "created_at", "updated_at"]
+}`)
+
+var companiesPageSchema = []byte(`{
+  "type": "object",
+  "properties": {
+    "companies": {"type": "array", "items": {"$ref": "#/components/schemas/Company"}},
+    "next_cursor": {"type": "string", "description": "Cursor of the next page, absent on the last page"},
+    "total": {"type": "integer", "description": "Number of matching companies, only with with_total=true"}
+  },
+  "required": ["companies"]
+}`)
+
+var companySearchPageSchema = []byte(`{
+  "type": "object",
+  "properties": {
+    "results": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": {
+          "company": {"$ref": "#/components/schemas/Company"},
+          "rank": {"type": "number"},
+          "highlights": {
+            "type": "object",
+            "properties": {
+              "name": {"type": "string"},
+              "description": {"type": "string"}
+            }
+          }
+        }
+      }
+    },
+    "next_cursor": {"type": "string"}
+  },
+  "required": ["results"]
+}`)
+
+var companyRevisionsPageSchema = []byte(`{
+  "type": "object",
+  "properties": {
+    "revisions": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": {
+          "id": {"type": "integer"},
+          "company_id": {"type": "string", "format": "uuid"},
+          "operation": {"type": "string", "enum": ["create", "update", "delete", "restore", "purge"]},
+          "version": {"type": "integer"},
+          "snapshot": {"$ref": "#/components/schemas/Company"},
+          "actor": {"type": "string"},
+          "request_id": {"type": "string"},
+          "created_at": {"type": "string", "format": "date-time"}
+        }
+      }
+    },
+    "next_cursor": {"type": "string"}
+  },
+  "required": ["revisions"]
+}`)
+
+var bulkResponseSchema = []byte(`{
+  "type": "object",
+  "properties": {
+    "results": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": {
+          "index": {"type": "integer"},
+          "id": {"type": "string", "format": "uuid"},
+          "status": {"type": "string", "enum": ["created", "updated", "skipped", "error"]},
+          "error": {"type": "string"},
+          "code": {"type": "string"},
+          "errors": {"type": "array", "items": {"$ref": "#/components/schemas/FieldError"}}
+        }
+      }
+    },
+    "summary": {
+      "type": "object",
+      "properties": {
+        "created": {"type": "integer"},
+        "updated": {"type": "integer"},
+        "skipped": {"type": "integer"},
+        "failed": {"type": "integer"}
+      }
+    }
+  }
+}`)
+
+var problemSchema = []byte(`{
+  "type": "object",
+  "description": "RFC 7807 problem details",
+  "properties": {
+    "type": {"type": "string"},
+    "title": {"type": "string"},
+    "status": {"type": "integer"},
+    "detail": {"type": "string"},
+    "code": {"type": "string", "description": "Machine-readable error code"},
+    "errors": {"type": "array", "items": {"$ref": "#/components/schemas/FieldError"}}
+  },
+  "required": ["type", "title", "status", "code"]
+}`)
+
+var fieldErrorSchema = []byte(`{
+  "type": "object",
+  "properties": {
+    "pointer": {"type": "string", "description": "JSON pointer to the offending field"},
+    "message": {"type": "string"}
+  },
+  "required": ["pointer", "message"]
+}`)
+
+var eventSchemasListSchema = []byte(`{
+  "type": "object",
+  "properties": {
+    "schemas": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": {
+          "type": {"type": "string", "description": "CloudEvents type of the events"},
+          "url": {"type": "string", "description": "Path of the JSON Schema of the events"}
+        },
+        "required": ["type", "url"]
+      }
+    }
+  },
+  "required": ["schemas"]
+}`)
+
+var webhookSubscriptionSchema = []byte(`{
+  "type": "object",
+  "properties": {
+    "id": {"type": "string", "format": "uuid"},
+    "url": {"type": "string"},
+    "event_types": {"type": "array", "items": {"type": "string"}, "description": "Delivered event types, all when empty"},
+    "description": {"type": "string"},
+    "secret": {"type": "string", "description": "Key the deliveries are signed with, only returned on creation"},
+    "enabled": {"type": "boolean"},
+    "disabled_reason": {"type": "string", "description": "Why the subscription was disabled automatically"},
+    "consecutive_failures": {"type": "integer", "description": "Deliveries given up in a row, after running out of attempts"},
+    "created_at": {"type": "string", "format": "date-time"},
+    "updated_at": {"type": "string", "format": "date-time"}
+  },
+  "required": ["id", "url", "event_types", "enabled", "consecutive_failures", "created_at", "updated_at"]
+}`)
+
+var webhookSubscriptionsPageSchema = []byte(`{
+  "type": "object",
+  "properties": {
+    "subscriptions": {"type": "array", "items": {"$ref": "#/components/schemas/WebhookSubscription"}},
+    "next_cursor": {"type": "string"}
+  },
+  "required": ["subscriptions"]
+}`)
+
+var webhookDeliveriesPageSchema = []byte(`{
+  "type": "object",
+  "properties": {
+    "deliveries": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": {
+          "id": {"type": "integer", "description": "Sent in the X-Webhook-Id header, the same for every attempt"},
+          "subscription_id": {"type": "string", "format": "uuid"},
+          "event_id": {"type": "string"},
+          "event_type": {"type": "string"},
+          "status": {"type": "string", "enum": ["pending", "succeeded", "failed"]},
+          "attempts": {"type": "integer"},
+          "response_status": {"type": "integer", "description": "HTTP status of the last response"},
+          "last_error": {"type": "string"},
+          "next_attempt_at": {"type": "string", "format": "date-time", "description": "Only while pending"},
+          "last_attempt_at": {"type": "string", "format": "date-time"},
+          "created_at": {"type": "string", "format": "date-time"},
+          "finished_at": {"type": "string", "format": "date-time"}
+        }
+      }
+    },
+    "next_cursor": {"type": "string"}
+  },
+  "required": ["deliveries"]
+}`)
+
+// OpenAPISchemas returns the component schemas referenced by the handlers' operations.
+// Request bodies are documented with the same schemas they are validated against.
+func OpenAPISchemas() map[string]json.RawMessage {
+	return map[string]json.RawMessage{
+		"CreateCompany":            specSchema(createCompaniesSchema),
+		"PatchCompany":             specSchema(patchCompaniesSchema),
+		"Company":                  companySchema,
+		"CompaniesPage":            companiesPageSchema,
+		"CompanySearchPage":        companySearchPageSchema,
+		"CompanyRevisionsPage":     companyRevisionsPageSchema,
+		"BulkResponse":             bulkResponseSchema,
+		"EventSchemasList":         eventSchemasListSchema,
+		"CreateWebhook":            specSchema(createWebhookSchema),
+		"PatchWebhook":             specSchema(patchWebhookSchema),
+		"WebhookSubscription":      webhookSubscriptionSchema,
+		"WebhookSubscriptionsPage": webhookSubscriptionsPageSchema,
+		"WebhookDeliveriesPage":    webhookDeliveriesPageSchema,
+		"Problem":                  problemSchema,
+		"FieldError":               fieldErrorSchema,
+	}
+}
+
+// specSchema drops the draft-07 $schema keyword, the OpenAPI 3.1 dialect applies to component schemas.
+func specSchema(schema []byte) json.RawMessage {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(schema, &fields); err != nil {
+		panic(err)
+	}
+	delete(fields, "$schema")
+	res, err := json.Marshal(fields)
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
+
+func jsonContent(schemaName string) map[string]json.RawMessage {
+	return map[string]json.RawMessage{ContentTypeAppJSON: openapi.Ref(schemaName)}
+}
+
+func paginationParams() []openapi.Parameter {
+	return []openapi.Parameter{
+		openapi.QueryParam("limit", fmt.Sprintf("Page size, 1-%d, default %d", maxPageLimit, defaultPageLimit), intSchema),
+		openapi.QueryParam("cursor", "next_cursor value from the previous page", stringSchema),
+	}
+}
+
+var (
+	ifMatchParam = openapi.HeaderParam("If-Match",
+		`Versions the company is expected to have, e.g. "3" or "3", "4"; 412 is returned when it has none of them`, stringSchema)
+	idempotencyKeyParam = openapi.HeaderParam("Idempotency-Key",
+		"Makes the request safe to retry, the first response is replayed for repeats", stringSchema)
+	companyIDParam = openapi.PathParam("id", "Company id", uuidSchema)
+	webhookIDParam = openapi.PathParam("id", "Webhook subscription id", uuidSchema)
+)
+
+func (h *CreateCompaniesHandler) OpenAPIOperation() openapi.Operation {
+	return openapi.Operation{
+		Summary:     "Create a company",
+		Description: "The id is generated by the server when omitted.",
+		Tags:        []string{tagCompanies},
+		Secured:     true,
+		Parameters:  []openapi.Parameter{idempotencyKeyParam},
+		RequestBody: &openapi.RequestBody{Required: true, Content: jsonContent("CreateCompany")},
+		Responses: map[int]openapi.Response{
+			http.StatusCreated: {
+				Description: "Company created",
+				Headers:     map[string]string{"Location": "URL of the company", "ETag": "Version of the company"},
+				Content:     jsonContent("Company"),
+			},
+		},
+	}
+}
+
+func (h *BulkCreateCompaniesHandler) OpenAPIOperation() openapi.Operation {
+	return openapi.Operation{
+		Summary:     "Create or upsert many companies",
+		Description: fmt.Sprintf("Up to %d items, each stored independently.", maxBulkItems),
+		Tags:        []string{tagCompanies},
+		Secured:     true,
+		Parameters: []openapi.Parameter{
+			openapi.QueryParam("on_conflict", "What to do with an existing company, default fail",
+				`{"type": "string", "enum": ["skip", "fail", "upsert"]}`),
+			idempotencyKeyParam,
+		},
+		RequestBody: &openapi.RequestBody{
+			Required: true,
+			Content: map[string]json.RawMessage{
+				ContentTypeAppJSON: json.RawMessage(`{"type": "array", "items": {"$ref": "#/components/schemas/CreateCompany"}}`),
+				ContentTypeNDJSON:  openapi.Ref("CreateCompany"),
+			},
+		},
+		Responses: map[int]openapi.Response{
+			http.StatusOK: {Description: "Outcome of every item", Content: jsonContent("BulkResponse")},
+		},
+	}
+}
+
+func (h *GetCompaniesHandler) OpenAPIOperation() openapi.Operation {
+	return openapi.Operation{
+		Summary:     "Get a company or list companies",
+		Description: "Returns the company identified by uuid or name, or a page of companies when neither is given.",
+		Tags:        []string{tagCompanies},
+		Parameters: append([]openapi.Parameter{
+			openapi.QueryParam("uuid", "Company id", uuidSchema),
+			openapi.QueryParam("name", "Company name, former names are resolved too", stringSchema),
+			openapi.QueryParam("as_of", "RFC 3339 timestamp, returns the company as it was then; requires uuid",
+				`{"type": "string", "format": "date-time"}`),
+			openapi.HeaderParam("If-None-Match", "ETag of a cached company, 304 is returned while unchanged", stringSchema),
+			openapi.QueryParam("type", "Filter by company type",
+				`{"type": "string", "enum": ["Corporations", "NonProfit", "Cooperative", "Sole Proprietorship"]}`),
+			openapi.QueryParam("registered", "Filter by registration flag", boolSchema),
+			openapi.QueryParam("min_employees_count", "Lower bound (inclusive) of employees_count", intSchema),
+			openapi.QueryParam("max_employees_count", "Upper bound (inclusive) of employees_count", intSchema),
+			openapi.QueryParam("sort", "Sort field, default name",
+				`{"type": "string", "enum": ["name", "employees_count", "created_at", "updated_at"]}`),
+			openapi.QueryParam("order", "Sort order, default asc", `{"type": "string", "enum": ["asc", "desc"]}`),
+			openapi.QueryParam("with_total", "Include the total number of matching companies", boolSchema),
+		}, paginationParams()...),
+		Responses: map[int]openapi.Response{
+			http.StatusOK: {
+				Description: "The company, or a page of companies",
+				Headers:     map[string]string{"ETag": "Version of the company"},
+				Content: map[string]json.RawMessage{
+					ContentTypeAppJSON: json.RawMessage(
+						`{"oneOf": [{"$ref": "#/components/schemas/Company"}, {"$ref": "#/components/schemas/CompaniesPage"}]}`),
+				},
+			},
+			http.StatusNotModified: {Description: "The company matches If-None-Match"},
+		},
+	}
+}
+
+func (h *SearchCompaniesHandler) OpenAPIOperation() openapi.Operation {
+	return openapi.Operation{
+		Summary: "Full-text search over company names and descriptions",
+		Tags:    []string{tagCompanies},
+		Parameters: append([]openapi.Parameter{
+			{
+				Name:        "q",
+				In:          "query",
+				Description: fmt.Sprintf("Search query, up to %d characters", maxSearchQueryLength),
+				Required:    true,
+				Schema:      json.RawMessage(stringSchema),
+			},
+		}, paginationParams()...),
+		Responses: map[int]openapi.Response{
+			http.StatusOK: {Description: "Matching companies, best first", Content: jsonContent("CompanySearchPage")},
+		},
+	}
+}
+
+func (h *CompanyEventsHandler) OpenAPIOperation() openapi.Operation {
+	return openapi.Operation{
+		Summary: "Stream of company changes",
+		Description: "Server-Sent Events stream of the changes made through this server instance. Every event " +
+			"carries the CloudEvent in the JSON event format as its data, e.g. event: company.created. A reset " +
+			"event is sent first when the stream can't be resumed from the last event id, events were missed.",
+		Tags: []string{tagCompanies},
+		Parameters: []openapi.Parameter{
+			openapi.QueryParam("company_id", "Only the events of this company", uuidSchema),
+			openapi.QueryParam("type", "Only the events of companies of this type",
+				`{"type": "string", "enum": ["Corporations", "NonProfit", "Cooperative", "Sole Proprietorship"]}`),
+			openapi.HeaderParam(HeaderLastEventID, "Id of the last event received, resumes the stream after it", stringSchema),
+			openapi.QueryParam("last_event_id", "Same as the Last-Event-ID header, which takes precedence", stringSchema),
+		},
+		Responses: map[int]openapi.Response{
+			http.StatusOK: {
+				Description: "The event stream",
+				Content:     map[string]json.RawMessage{ContentTypeEventStream: json.RawMessage(stringSchema)},
+			},
+			http.StatusServiceUnavailable: {Description: "The server is shutting down"},
+		},
+	}
+}
+
+func (h *DeleteCompaniesHandler) OpenAPIOperation() openapi.Operation {
+	return openapi.Operation{
+		Summary:     "Delete a company",
+		Description: "The company is only marked as deleted and can be restored until it is purged.",
+		Tags:        []string{tagCompanies},
+		Secured:     true,
+		Parameters: []openapi.Parameter{
+			openapi.QueryParam("uuid", "Company id", uuidSchema),
+			openapi.QueryParam("name", "Company name", stringSchema),
+			ifMatchParam,
+			idempotencyKeyParam,
+		},
+		Responses: map[int]openapi.Response{
+			http.StatusNoContent: {Description: "Company deleted"},
+		},
+	}
+}
+
+func (h *PatchCompaniesHandler) OpenAPIOperation() openapi.Operation {
+	return openapi.Operation{
+		Summary:     "Update a company",
+		Description: "Without id the name identifies the company, with id a name renames it.",
+		Tags:        []string{tagCompanies},
+		Secured:     true,
+		Parameters:  []openapi.Parameter{ifMatchParam, idempotencyKeyParam},
+		RequestBody: &openapi.RequestBody{Required: true, Content: jsonContent("PatchCompany")},
+		Responses: map[int]openapi.Response{
+			http.StatusOK: {
+				Description: "Company updated",
+				Headers:     map[string]string{"ETag": "New version of the company"},
+			},
+		},
+	}
+}
+
+func (h *RestoreCompaniesHandler) OpenAPIOperation() openapi.Operation {
+	return openapi.Operation{
+		Summary:    "Restore a deleted company",
+		Tags:       []string{tagCompanies},
+		Secured:    true,
+		Parameters: []openapi.Parameter{companyIDParam, idempotencyKeyParam},
+		Responses: map[int]openapi.Response{
+			http.StatusOK: {
+				Description: "Company restored",
+				Headers:     map[string]string{"ETag": "Version of the company"},
+				Content:     jsonContent("Company"),
+			},
+		},
+	}
+}
+
+func (h *ListRevisionsHandler) OpenAPIOperation() openapi.Operation {
+	return openapi.Operation{
+		Summary:    "Change history of a company",
+		Tags:       []string{tagCompanies},
+		Secured:    true,
+		Parameters: append([]openapi.Parameter{companyIDParam}, paginationParams()...),
+		Responses: map[int]openapi.Response{
+			http.StatusOK: {Description: "Revisions, newest first", Content: jsonContent("CompanyRevisionsPage")},
+		},
+	}
+}
+
+func (h *ListEventSchemasHandler) OpenAPIOperation() openapi.Operation {
+	return openapi.Operation{
+		Summary: "List the event schemas",
+		Tags:    []string{tagEvents},
+		Responses: map[int]openapi.Response{
+			http.StatusOK: {Description: "Published event types and their schemas", Content: jsonContent("EventSchemasList")},
+		},
+	}
+}
+
+func (h *GetEventSchemaHandler) OpenAPIOperation() openapi.Operation {
+	return openapi.Operation{
+		Summary:     "Get an event schema",
+		Description: "JSON Schema of the CloudEvents envelope and data of an event type, e.g. company.created.v1.json.",
+		Tags:        []string{tagEvents},
+		Parameters:  []openapi.Parameter{openapi.PathParam("name", "File name of the schema", stringSchema)},
+		Responses: map[int]openapi.Response{
+			http.StatusOK: {
+				Description: "JSON Schema (draft-07)",
+				Content:     map[string]json.RawMessage{ContentTypeSchemaJSON: json.RawMessage(`{"type": "object"}`)},
+			},
+		},
+	}
+}
+
+func (h *CreateWebhookHandler) OpenAPIOperation() openapi.Operation {
+	return openapi.Operation{
+		Summary:     "Subscribe a webhook",
+		Description: "The secret is generated by the server when omitted, it's only returned in this response.",
+		Tags:        []string{tagWebhooks},
+		Secured:     true,
+		Parameters:  []openapi.Parameter{idempotencyKeyParam},
+		RequestBody: &openapi.RequestBody{Required: true, Content: jsonContent("CreateWebhook")},
+		Responses: map[int]openapi.Response{
+			http.StatusCreated: {
+				Description: "Subscription created",
+				Headers:     map[string]string{"Location": "URL of the subscription"},
+				Content:     jsonContent("WebhookSubscription"),
+			},
+		},
+	}
+}
+
+func (h *ListWebhooksHandler) OpenAPIOperation() openapi.Operation {
+	return openapi.Operation{
+		Summary:    "List the webhook subscriptions",
+		Tags:       []string{tagWebhooks},
+		Secured:    true,
+		Parameters: paginationParams(),
+		Responses: map[int]openapi.Response{
+			http.StatusOK: {Description: "Subscriptions, oldest first", Content: jsonContent("WebhookSubscriptionsPage")},
+		},
+	}
+}
+
+func (h *GetWebhookHandler) OpenAPIOperation() openapi.Operation {
+	return openapi.Operation{
+		Summary:    "Get a webhook subscription",
+		Tags:       []string{tagWebhooks},
+		Secured:    true,
+		Parameters: []openapi.Parameter{webhookIDParam},
+		Responses: map[int]openapi.Response{
+			http.StatusOK: {Description: "The subscription", Content: jsonContent("WebhookSubscription")},
+		},
+	}
+}
+
+func (h *PatchWebhookHandler) OpenAPIOperation() openapi.Operation {
+	return openapi.Operation{
+		Summary:     "Update a webhook subscription",
+		Description: "Enabling a disabled subscription resets its failure count.",
+		Tags:        []string{tagWebhooks},
+		Secured:     true,
+		Parameters:  []openapi.Parameter{webhookIDParam, idempotencyKeyParam},
+		RequestBody: &openapi.RequestBody{Required: true, Content: jsonContent("PatchWebhook")},
+		Responses: map[int]openapi.Response{
+			http.StatusOK: {Description: "Subscription updated", Content: jsonContent("WebhookSubscription")},
+		},
+	}
+}
+
+func (h *DeleteWebhookHandler) OpenAPIOperation() openapi.Operation {
+	return openapi.Operation{
+		Summary:     "Delete a webhook subscription",
+		Description: "Pending deliveries are dropped along with the delivery log.",
+		Tags:        []string{tagWebhooks},
+		Secured:     true,
+		Parameters:  []openapi.Parameter{webhookIDParam, idempotencyKeyParam},
+		Responses: map[int]openapi.Response{
+			http.StatusNoContent: {Description: "Subscription deleted"},
+		},
+	}
+}
+
+func (h *ListWebhookDeliveriesHandler) OpenAPIOperation() openapi.Operation {
+	return openapi.Operation{
+		Summary: "Delivery log of a webhook subscription",
+		Tags:    []string{tagWebhooks},
+		Secured: true,
+		Parameters: append([]openapi.Parameter{
+			webhookIDParam,
+			openapi.QueryParam("status", "Filter by delivery status",
+				`{"type": "string", "enum": ["pending", "succeeded", "failed"]}`),
+		}, paginationParams()...),
+		Responses: map[int]openapi.Response{
+			http.StatusOK: {Description: "Deliveries, newest first", Content: jsonContent("WebhookDeliveriesPage")},
+		},
+	}
+}
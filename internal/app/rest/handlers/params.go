@@ -3,7 +3,10 @@ package handlers
 import (
 	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 
 	"github.com/faeelol/companies-store/internal/app/apperrors"
@@ -36,3 +39,62 @@ func getUUIDParam(r *http.Request, key string, isRequired bool) (uuid.UUID, erro
 	}
 	return resUUID, nil
 }
+
+func getIntParam(r *http.Request, key string, isRequired bool) (*int, error) {
+	raw, err := getStringParam(r, key, isRequired)
+	if err != nil || raw == "" {
+		return nil, err
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		return nil, apperrors.NewBadRequestError(fmt.Sprintf("invalid %s param", key))
+	}
+	return &v, nil
+}
+
+func getBoolParam(r *http.Request, key string, isRequired bool) (*bool, error) {
+	raw, err := getStringParam(r, key, isRequired)
+	if err != nil || raw == "" {
+		return nil, err
+	}
+	v, err := strconv.ParseBool(raw)
+	if err != nil {
+		return nil, apperrors.NewBadRequestError(fmt.Sprintf("invalid %s param", key))
+	}
+	return &v, nil
+}
+
+func getTimeParam(r *http.Request, key string, isRequired bool) (*time.Time, error) {
+	raw, err := getStringParam(r, key, isRequired)
+	if err != nil || raw == "" {
+		return nil, err
+	}
+	v, err := time.Parse(time.RFC3339Nano, raw)
+	if err != nil {
+		return nil, apperrors.NewBadRequestError(fmt.Sprintf("invalid %s param, RFC 3339 timestamp expected", key))
+	}
+	return &v, nil
+}
+
+func getUUIDURLParam(r *http.Request, key string) (uuid.UUID, error) {
+	resUUID, err := uuid.Parse(chi.URLParam(r, key))
+	if err != nil {
+		return uuid.Nil, apperrors.NewBadRequestError(fmt.Sprintf("invalid %s in path", key))
+	}
+	return resUUID, nil
+}
+
+// getLimitParam returns the page size, defaultPageLimit when the limit param is omitted.
+func getLimitParam(r *http.Request) (int, error) {
+	limit, err := getIntParam(r, "limit", false)
+	if err != nil {
+		return 0, err
+	}
+	if limit == nil {
+		return defaultPageLimit, nil
+	}
+	if *limit < 1 || *limit > maxPageLimit {
+		return 0, apperrors.NewBadRequestError(fmt.Sprintf("limit param must be between 1 and %d", maxPageLimit))
+	}
+	return *limit, nil
+}
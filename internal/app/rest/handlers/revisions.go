@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/faeelol/companies-store/internal/app/model"
+	"github.com/faeelol/companies-store/internal/app/rest/middlewares"
+)
+
+type ListRevisionsController interface {
+	ListRevisions(ctx context.Context, params model.ListRevisionsParams) (model.CompanyRevisionsPage, error)
+}
+
+type ListRevisionsHandler struct {
+	lrc ListRevisionsController
+}
+
+func NewListRevisionsHandler(lrc ListRevisionsController) *ListRevisionsHandler {
+	return &ListRevisionsHandler{
+		lrc: lrc,
+	}
+}
+
+func (h *ListRevisionsHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	logger := middlewares.GetLoggerFromContext(r.Context())
+	ctx := r.Context()
+
+	id, err := getUUIDURLParam(r, "id")
+	if err != nil {
+		RespondError(rw, err, logger)
+		return
+	}
+
+	params := model.ListRevisionsParams{
+		CompanyID: id,
+	}
+
+	if params.Limit, err = getLimitParam(r); err != nil {
+		RespondError(rw, err, logger)
+		return
+	}
+
+	params.Cursor, _ = getStringParam(r, "cursor", false)
+
+	res, err := h.lrc.ListRevisions(ctx, params)
+	if err != nil {
+		RespondError(rw, err, logger)
+		return
+	}
+
+	RespondCodeAndJSON(rw, http.StatusOK, res, nil)
+}
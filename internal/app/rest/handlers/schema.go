@@ -7,7 +7,7 @@ var createCompaniesSchema = []byte(`{
     "id": {
       "type": "string",
       "format": "uuid",
-      "description": "A unique identifier for the company (UUID format)"
+      "description": "A unique identifier for the company (UUID format), generated by the server when omitted"
     },
     "name": {
       "type": "string",
@@ -17,8 +17,7 @@ var createCompaniesSchema = []byte(`{
     "description": {
       "type": "string",
       "maxLength": 3000,
-      "description": "An optional description of the company, up to 3000 characters",
-      "nullable": true
+      "description": "An optional description of the company, up to 3000 characters"
     },
     "employees_count": {
       "type": "integer",
@@ -40,7 +39,7 @@ var createCompaniesSchema = []byte(`{
       "description": "The type of the company, must be one of the predefined values"
     }
   },
-  "required": ["id", "name", "employees_count", "registered", "type"],
+  "required": ["name", "employees_count", "registered", "type"],
   "additionalProperties": false
 }`)
 
@@ -61,8 +60,7 @@ var patchCompaniesSchema = []byte(`{
     "description": {
       "type": "string",
       "maxLength": 3000,
-      "description": "An optional description of the company, up to 3000 characters",
-      "nullable": true
+      "description": "An optional description of the company, up to 3000 characters"
     },
     "employees_count": {
       "type": "integer",
@@ -85,9 +83,85 @@ var patchCompaniesSchema = []byte(`{
     }
   },
   "required": [],
-  "oneOf": [
+  "anyOf": [
     { "required": ["id"] },
     { "required": ["name"] }
   ],
   "additionalProperties": false
 }`)
+
+var createWebhookSchema = webhookSchema(`{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "type": "object",
+  "properties": {
+    "url": {
+      "type": "string",
+      "format": "uri",
+      "pattern": "^https?://",
+      "maxLength": 2048,
+      "description": "The http or https URL the events are posted to"
+    },
+    "event_types": {
+      "type": "array",
+      "items": {
+        "type": "string",
+        "enum": {{event_types}}
+      },
+      "uniqueItems": true,
+      "description": "The event types to deliver, all of them when empty or omitted"
+    },
+    "secret": {
+      "type": "string",
+      "minLength": 16,
+      "maxLength": 256,
+      "description": "The key the deliveries are signed with, generated by the server when omitted"
+    },
+    "description": {
+      "type": "string",
+      "maxLength": 500,
+      "description": "An optional note about the subscription"
+    }
+  },
+  "required": ["url"],
+  "additionalProperties": false
+}`)
+
+var patchWebhookSchema = webhookSchema(`{
+  "$schema": "http://json-schema.org/draft-07/schema#",
+  "type": "object",
+  "properties": {
+    "url": {
+      "type": "string",
+      "format": "uri",
+      "pattern": "^https?://",
+      "maxLength": 2048,
+      "description": "The http or https URL the events are posted to"
+    },
+    "event_types": {
+      "type": "array",
+      "items": {
+        "type": "string",
+        "enum": {{event_types}}
+      },
+      "uniqueItems": true,
+      "description": "The event types to deliver, all of them when empty"
+    },
+    "secret": {
+      "type": "string",
+      "minLength": 16,
+      "maxLength": 256,
+      "description": "A new key to sign the deliveries with"
+    },
+    "description": {
+      "type": "string",
+      "maxLength": 500,
+      "description": "An optional note about the subscription"
+    },
+    "enabled": {
+      "type": "boolean",
+      "description": "Enables or disables the subscription, enabling it resets its failure count"
+    }
+  },
+  "minProperties": 1,
+  "additionalProperties": false
+}`)
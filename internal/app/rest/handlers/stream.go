@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/faeelol/companies-store/internal/app/apperrors"
+	"github.com/faeelol/companies-store/internal/app/logic/stream"
+	"github.com/faeelol/companies-store/internal/app/model"
+	"github.com/faeelol/companies-store/internal/app/rest/middlewares"
+)
+
+const (
+	ContentTypeEventStream = "text/event-stream"
+
+	HeaderLastEventID = "Last-Event-ID"
+
+	// streamResetEvent tells the client the stream couldn't be resumed, so it has to reload the
+	// companies to catch up on the events it missed.
+	streamResetEvent = "reset"
+)
+
+type CompanyEventsBroker interface {
+	Subscribe(lastEventID string) (*stream.Subscription, []stream.Event, bool, error)
+	Unsubscribe(sub *stream.Subscription)
+	Config() *stream.Config
+}
+
+type CompanyEventsHandler struct {
+	broker CompanyEventsBroker
+}
+
+func NewCompanyEventsHandler(broker CompanyEventsBroker) *CompanyEventsHandler {
+	return &CompanyEventsHandler{
+		broker: broker,
+	}
+}
+
+type companyEventsFilter struct {
+	companyID   uuid.UUID
+	companyType *model.CompanyType
+}
+
+func (f companyEventsFilter) matches(event stream.Event) bool {
+	if f.companyID != uuid.Nil && event.CompanyID != f.companyID {
+		return false
+	}
+	if f.companyType != nil && event.CompanyType != *f.companyType {
+		return false
+	}
+	return true
+}
+
+func (h *CompanyEventsHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	logger := middlewares.GetLoggerFromContext(r.Context())
+	ctx := r.Context()
+
+	var filter companyEventsFilter
+	var err error
+	if filter.companyID, err = getUUIDParam(r, "company_id", false); err != nil {
+		RespondError(rw, err, logger)
+		return
+	}
+	if rawType, _ := getStringParam(r, "type", false); rawType != "" {
+		companyType := model.CompanyType(rawType)
+		if !companyType.IsValid() {
+			RespondError(rw, apperrors.NewBadRequestError("invalid type param"), logger)
+			return
+		}
+		filter.companyType = &companyType
+	}
+
+	// EventSource can't set headers on the first connection, so the id can be passed as a param too
+	lastEventID := r.Header.Get(HeaderLastEventID)
+	if lastEventID == "" {
+		lastEventID, _ = getStringParam(r, "last_event_id", false)
+	}
+
+	sub, replay, resumed, err := h.broker.Subscribe(lastEventID)
+	if errors.Is(err, stream.ErrClosed) {
+		RespondError(rw, apperrors.NewServiceUnavailableError("server is shutting down"), logger)
+		return
+	}
+	if err != nil {
+		RespondError(rw, err, logger)
+		return
+	}
+	defer h.broker.Unsubscribe(sub)
+
+	cfg := h.broker.Config()
+	w := &eventStreamWriter{
+		w:            rw,
+		rc:           http.NewResponseController(rw),
+		writeTimeout: cfg.WriteTimeout,
+	}
+
+	rw.Header().Set("Content-Type", ContentTypeEventStream)
+	rw.Header().Set("Cache-Control", "no-cache")
+	// keeps nginx from buffering the stream
+	rw.Header().Set("X-Accel-Buffering", "no")
+	rw.WriteHeader(http.StatusOK)
+
+	if err := w.write(fmt.Sprintf("retry: %d\n\n", cfg.Retry.Milliseconds())); err != nil {
+		return
+	}
+	if !resumed {
+		if err := w.write(fmt.Sprintf("event: %s\ndata: {}\n\n", streamResetEvent)); err != nil {
+			return
+		}
+	}
+	for _, event := range replay {
+		if !filter.matches(event) {
+			continue
+		}
+		if err := w.writeEvent(event); err != nil {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(cfg.HeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			if !filter.matches(event) {
+				continue
+			}
+			if err := w.writeEvent(event); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if err := w.write(": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// eventStreamWriter writes to the stream, flushing every write. The server write timeout
+// doesn't fit a long-lived response, so every write gets its own deadline instead.
+type eventStreamWriter struct {
+	w            io.Writer
+	rc           *http.ResponseController
+	writeTimeout time.Duration
+}
+
+func (w *eventStreamWriter) writeEvent(event stream.Event) error {
+	// the JSON encoding of an event has no newlines, so it fits a single data line
+	return w.write(fmt.Sprintf("id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type.Name(), event.Data))
+}
+
+func (w *eventStreamWriter) write(s string) error {
+	if err := w.rc.SetWriteDeadline(time.Now().Add(w.writeTimeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	if _, err := io.WriteString(w.w, s); err != nil {
+		return err
+	}
+	return w.rc.Flush()
+}
@@ -0,0 +1,283 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/xeipuuv/gojsonschema"
+
+	"github.com/faeelol/companies-store/internal/app/apperrors"
+	"github.com/faeelol/companies-store/internal/app/events"
+	"github.com/faeelol/companies-store/internal/app/model"
+	"github.com/faeelol/companies-store/internal/app/rest/middlewares"
+)
+
+var (
+	createWebhookJSONSchema = mustJSONSchema(createWebhookSchema)
+	patchWebhookJSONSchema  = mustJSONSchema(patchWebhookSchema)
+)
+
+// webhookSchema fills the event types a subscription can ask for into the schema, so they follow
+// the published ones.
+func webhookSchema(schema string) []byte {
+	// a list of strings always marshals
+	eventTypes, _ := json.Marshal(events.ChangeTypes)
+	return []byte(strings.ReplaceAll(schema, "{{event_types}}", string(eventTypes)))
+}
+
+// webhookLocation is the URL the webhook subscription can be fetched from.
+func webhookLocation(id uuid.UUID) string {
+	return fmt.Sprintf("%s/webhooks/%s", APIBasePath, id)
+}
+
+// checkWebhookURL rejects URLs the schema lets through but no request can be made to.
+func checkWebhookURL(rawURL *string) error {
+	if rawURL == nil {
+		return nil
+	}
+	if u, err := url.Parse(*rawURL); err != nil || u.Host == "" {
+		return apperrors.NewValidationError("request body doesn't match the schema", []apperrors.FieldError{
+			{Pointer: "/url", Message: "url must have a host"},
+		})
+	}
+	return nil
+}
+
+type CreateWebhookController interface {
+	CreateSubscription(ctx context.Context, data model.CreateWebhookData) (model.WebhookSubscription, error)
+}
+
+type CreateWebhookHandler struct {
+	schema *gojsonschema.Schema
+	cwc    CreateWebhookController
+}
+
+func NewCreateWebhookHandler(cwc CreateWebhookController) *CreateWebhookHandler {
+	return &CreateWebhookHandler{
+		schema: createWebhookJSONSchema,
+		cwc:    cwc,
+	}
+}
+
+func (h *CreateWebhookHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	logger := middlewares.GetLoggerFromContext(r.Context())
+	ctx := r.Context()
+
+	var data model.CreateWebhookData
+	if err := ParseRequestJSON(r, h.schema, &data); err != nil {
+		RespondError(rw, err, logger)
+		return
+	}
+	if err := checkWebhookURL(&data.URL); err != nil {
+		RespondError(rw, err, logger)
+		return
+	}
+
+	created, err := h.cwc.CreateSubscription(ctx, data)
+	if err != nil {
+		RespondError(rw, err, logger)
+		return
+	}
+
+	rw.Header().Set("Location", webhookLocation(created.ID))
+	RespondCodeAndJSON(rw, http.StatusCreated, created, logger)
+}
+
+type ListWebhooksController interface {
+	ListSubscriptions(ctx context.Context, params model.ListWebhooksParams) (model.WebhookSubscriptionsPage, error)
+}
+
+type ListWebhooksHandler struct {
+	lwc ListWebhooksController
+}
+
+func NewListWebhooksHandler(lwc ListWebhooksController) *ListWebhooksHandler {
+	return &ListWebhooksHandler{
+		lwc: lwc,
+	}
+}
+
+func (h *ListWebhooksHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	logger := middlewares.GetLoggerFromContext(r.Context())
+	ctx := r.Context()
+
+	limit, err := getLimitParam(r)
+	if err != nil {
+		RespondError(rw, err, logger)
+		return
+	}
+	cursor, _ := getStringParam(r, "cursor", false)
+
+	res, err := h.lwc.ListSubscriptions(ctx, model.ListWebhooksParams{Limit: limit, Cursor: cursor})
+	if err != nil {
+		RespondError(rw, err, logger)
+		return
+	}
+
+	RespondCodeAndJSON(rw, http.StatusOK, res, nil)
+}
+
+type GetWebhookController interface {
+	GetSubscription(ctx context.Context, id uuid.UUID) (model.WebhookSubscription, error)
+}
+
+type GetWebhookHandler struct {
+	gwc GetWebhookController
+}
+
+func NewGetWebhookHandler(gwc GetWebhookController) *GetWebhookHandler {
+	return &GetWebhookHandler{
+		gwc: gwc,
+	}
+}
+
+func (h *GetWebhookHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	logger := middlewares.GetLoggerFromContext(r.Context())
+	ctx := r.Context()
+
+	id, err := getUUIDURLParam(r, "id")
+	if err != nil {
+		RespondError(rw, err, logger)
+		return
+	}
+
+	res, err := h.gwc.GetSubscription(ctx, id)
+	if err != nil {
+		RespondError(rw, err, logger)
+		return
+	}
+
+	RespondCodeAndJSON(rw, http.StatusOK, res, nil)
+}
+
+type PatchWebhookController interface {
+	UpdateSubscription(ctx context.Context, id uuid.UUID, updates model.UpdateWebhookData) (model.WebhookSubscription, error)
+}
+
+type PatchWebhookHandler struct {
+	schema *gojsonschema.Schema
+	pwc    PatchWebhookController
+}
+
+func NewPatchWebhookHandler(pwc PatchWebhookController) *PatchWebhookHandler {
+	return &PatchWebhookHandler{
+		schema: patchWebhookJSONSchema,
+		pwc:    pwc,
+	}
+}
+
+func (h *PatchWebhookHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	logger := middlewares.GetLoggerFromContext(r.Context())
+	ctx := r.Context()
+
+	id, err := getUUIDURLParam(r, "id")
+	if err != nil {
+		RespondError(rw, err, logger)
+		return
+	}
+
+	var updates model.UpdateWebhookData
+	if err := ParseRequestJSON(r, h.schema, &updates); err != nil {
+		RespondError(rw, err, logger)
+		return
+	}
+	if err := checkWebhookURL(updates.URL); err != nil {
+		RespondError(rw, err, logger)
+		return
+	}
+
+	updated, err := h.pwc.UpdateSubscription(ctx, id, updates)
+	if err != nil {
+		RespondError(rw, err, logger)
+		return
+	}
+
+	RespondCodeAndJSON(rw, http.StatusOK, updated, logger)
+}
+
+type DeleteWebhookController interface {
+	DeleteSubscription(ctx context.Context, id uuid.UUID) error
+}
+
+type DeleteWebhookHandler struct {
+	dwc DeleteWebhookController
+}
+
+func NewDeleteWebhookHandler(dwc DeleteWebhookController) *DeleteWebhookHandler {
+	return &DeleteWebhookHandler{
+		dwc: dwc,
+	}
+}
+
+func (h *DeleteWebhookHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	logger := middlewares.GetLoggerFromContext(r.Context())
+	ctx := r.Context()
+
+	id, err := getUUIDURLParam(r, "id")
+	if err != nil {
+		RespondError(rw, err, logger)
+		return
+	}
+
+	if err := h.dwc.DeleteSubscription(ctx, id); err != nil {
+		RespondError(rw, err, logger)
+		return
+	}
+
+	RespondCodeAndJSON(rw, http.StatusNoContent, nil, nil)
+}
+
+type ListWebhookDeliveriesController interface {
+	ListDeliveries(ctx context.Context, params model.ListWebhookDeliveriesParams) (model.WebhookDeliveriesPage, error)
+}
+
+type ListWebhookDeliveriesHandler struct {
+	ldc ListWebhookDeliveriesController
+}
+
+func NewListWebhookDeliveriesHandler(ldc ListWebhookDeliveriesController) *ListWebhookDeliveriesHandler {
+	return &ListWebhookDeliveriesHandler{
+		ldc: ldc,
+	}
+}
+
+func (h *ListWebhookDeliveriesHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	logger := middlewares.GetLoggerFromContext(r.Context())
+	ctx := r.Context()
+
+	id, err := getUUIDURLParam(r, "id")
+	if err != nil {
+		RespondError(rw, err, logger)
+		return
+	}
+
+	params := model.ListWebhookDeliveriesParams{SubscriptionID: id}
+
+	if rawStatus, _ := getStringParam(r, "status", false); rawStatus != "" {
+		status := model.WebhookDeliveryStatus(rawStatus)
+		if !status.IsValid() {
+			RespondError(rw, apperrors.NewBadRequestError("invalid status param"), logger)
+			return
+		}
+		params.Status = &status
+	}
+
+	if params.Limit, err = getLimitParam(r); err != nil {
+		RespondError(rw, err, logger)
+		return
+	}
+	params.Cursor, _ = getStringParam(r, "cursor", false)
+
+	res, err := h.ldc.ListDeliveries(ctx, params)
+	if err != nil {
+		RespondError(rw, err, logger)
+		return
+	}
+
+	RespondCodeAndJSON(rw, http.StatusOK, res, nil)
+}
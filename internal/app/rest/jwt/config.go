@@ -1,6 +1,8 @@
 package jwt
 
 import (
+	"errors"
+
 	"github.com/spf13/viper"
 )
 
@@ -22,3 +24,14 @@ func LoadJWTConfig() *Config {
 		TrustedIssuers: viper.GetStringSlice("server.jwt.trusted_issuers"),
 	}
 }
+
+func (c *Config) Validate() error {
+	var errs []error
+	if c.SecretKey == "" {
+		errs = append(errs, errors.New("server.jwt.secret_key is required"))
+	}
+	if len(c.TrustedIssuers) == 0 {
+		errs = append(errs, errors.New("server.jwt.trusted_issuers needs at least one issuer"))
+	}
+	return errors.Join(errs...)
+}
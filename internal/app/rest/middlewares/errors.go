@@ -2,11 +2,13 @@
 package middlewares
 
 import (
-	"encoding/json"
 	"fmt"
 	"net/http"
 
 	"github.com/sirupsen/logrus"
+
+	"github.com/faeelol/companies-store/internal/app/apperrors"
+	"github.com/faeelol/companies-store/internal/app/rest/problem"
 )
 
 func NewErrorHandlerMiddleware(logger logrus.FieldLogger) func(next http.Handler) http.Handler {
@@ -22,11 +24,7 @@ func NewErrorHandlerMiddleware(logger logrus.FieldLogger) func(next http.Handler
 						"error":  errMessage,
 					}).Error("Unhandled error")
 
-					rw.Header().Set("Content-Type", "application/json")
-					rw.WriteHeader(http.StatusInternalServerError)
-					_ = json.NewEncoder(rw).Encode(map[string]string{
-						"error": "Internal Server Error",
-					})
+					_ = problem.Write(rw, apperrors.NewInternalServerError("Internal Server Error"))
 				}
 			}()
 			next.ServeHTTP(rw, r)
@@ -0,0 +1,197 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/faeelol/companies-store/internal/app/apperrors"
+	"github.com/faeelol/companies-store/internal/app/rest/problem"
+)
+
+const (
+	HeaderIdempotencyKey      = "Idempotency-Key"
+	HeaderIdempotentReplayed  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	maxIdempotentRequestBytes = 32 << 20
+)
+
+const (
+	CodeIdempotentRequestInProgress = "idempotent_request_in_progress"
+	CodeIdempotencyKeyReused        = "idempotency_key_reused"
+)
+
+var (
+	ErrIdempotentRequestInProgress = errors.New("a request with this idempotency key is still in progress")
+	ErrIdempotencyKeyReused        = errors.New("idempotency key has already been used for a different request")
+)
+
+// StoredResponse is a response recorded for an idempotency key.
+type StoredResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+type IdempotencyStore interface {
+	Begin(ctx context.Context, scope, key, requestHash string) (*StoredResponse, error)
+	KeepLocked(ctx context.Context, scope, key string) (stop func())
+	Complete(ctx context.Context, scope, key string, resp StoredResponse) error
+	Release(ctx context.Context, scope, key string) error
+}
+
+// IdempotencyMiddleware makes POST, PATCH and DELETE requests carrying an Idempotency-Key header
+// safe to retry: the first response is stored and replayed for repeats of the same request, while
+// reusing the key for a different request is rejected. Keys are scoped to the JWT subject, so the
+// middleware has to run after the JWT one.
+type IdempotencyMiddleware struct {
+	store IdempotencyStore
+}
+
+func NewIdempotencyMiddleware(store IdempotencyStore) *IdempotencyMiddleware {
+	return &IdempotencyMiddleware{store: store}
+}
+
+func (m *IdempotencyMiddleware) Handle(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(HeaderIdempotencyKey)
+		if key == "" || !isIdempotencyApplicable(r.Method) {
+			next.ServeHTTP(rw, r)
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			_ = problem.Write(rw, apperrors.NewBadRequestError("Idempotency-Key header is too long"))
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(rw, r.Body, maxIdempotentRequestBytes))
+		if err != nil {
+			_ = problem.Write(rw, apperrors.NewBadRequestError("failed to read request body"))
+			return
+		}
+		_ = r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := r.Context()
+		logger := GetLoggerFromContext(ctx)
+		scope := GetSubjectFromContext(ctx)
+		// the outcome is stored even if the client goes away meanwhile
+		storeCtx := context.WithoutCancel(ctx)
+
+		stored, err := m.store.Begin(storeCtx, scope, key, hashRequest(r, body))
+		switch {
+		case errors.Is(err, ErrIdempotentRequestInProgress):
+			_ = problem.Write(rw, apperrors.NewConflictError(err.Error()).WithErrorCode(CodeIdempotentRequestInProgress))
+			return
+		case errors.Is(err, ErrIdempotencyKeyReused):
+			_ = problem.Write(rw, apperrors.NewUnprocessableError(err.Error()).WithErrorCode(CodeIdempotencyKeyReused))
+			return
+		case err != nil:
+			if logger != nil {
+				logger.WithField("error", err).Error("failed to check idempotency key")
+			}
+			_ = problem.Write(rw, apperrors.NewInternalServerError("Internal Server Error"))
+			return
+		}
+
+		if stored != nil {
+			replay(rw, stored)
+			return
+		}
+
+		recorder := &recordingResponseWriter{ResponseWriter: rw}
+		completed := false
+		stopLocking := m.store.KeepLocked(storeCtx, scope, key)
+		defer func() {
+			stopLocking()
+			if completed {
+				return
+			}
+			// let the request be retried with the same key after a failure
+			if err := m.store.Release(storeCtx, scope, key); err != nil && logger != nil {
+				logger.WithField("error", err).Error("failed to release idempotency key")
+			}
+		}()
+
+		next.ServeHTTP(recorder, r)
+
+		if recorder.Status() >= http.StatusInternalServerError {
+			return
+		}
+
+		header := rw.Header().Clone()
+		header.Del(HeaderRequestID)
+		err = m.store.Complete(storeCtx, scope, key, StoredResponse{
+			StatusCode: recorder.Status(),
+			Header:     header,
+			Body:       recorder.body.Bytes(),
+		})
+		if err != nil {
+			if logger != nil {
+				logger.WithField("error", err).Error("failed to store idempotent response")
+			}
+			return
+		}
+		completed = true
+	})
+}
+
+func isIdempotencyApplicable(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// hashRequest fingerprints the request, so a key reused for another request can be detected.
+func hashRequest(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + "\n" + r.URL.Path + "\n" + r.URL.RawQuery + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func replay(rw http.ResponseWriter, stored *StoredResponse) {
+	for name, values := range stored.Header {
+		rw.Header()[name] = values
+	}
+	rw.Header().Set(HeaderIdempotentReplayed, "true")
+	rw.WriteHeader(stored.StatusCode)
+	_, _ = rw.Write(stored.Body)
+}
+
+type recordingResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (rw *recordingResponseWriter) WriteHeader(code int) {
+	if !rw.wroteHeader {
+		rw.status = code
+		rw.wroteHeader = true
+		rw.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (rw *recordingResponseWriter) Write(buf []byte) (int, error) {
+	if !rw.wroteHeader {
+		rw.WriteHeader(http.StatusOK)
+	}
+	rw.body.Write(buf)
+	return rw.ResponseWriter.Write(buf)
+}
+
+func (rw *recordingResponseWriter) Status() int {
+	if !rw.wroteHeader {
+		return http.StatusOK
+	}
+	return rw.status
+}
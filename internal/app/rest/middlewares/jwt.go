@@ -7,6 +7,10 @@ import (
 	"strings"
 
 	"github.com/golang-jwt/jwt/v4"
+
+	"github.com/faeelol/companies-store/internal/app/apperrors"
+	"github.com/faeelol/companies-store/internal/app/rest/problem"
+	"github.com/faeelol/companies-store/internal/app/tracing"
 )
 
 const ctxKeyClaims ctxKey = "claims"
@@ -26,30 +30,42 @@ func NewJWTMiddleware(jwtParser JWTParser, requiredRoles []string) *JWTMiddlewar
 
 func (m *JWTMiddleware) VerifyToken(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
-
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
-			http.Error(rw, "missing or invalid Authorization header", http.StatusUnauthorized)
+		_, span := tracing.StartSpan(r.Context(), "jwt.verify")
+		claims, err := m.Authenticate(r.Header.Get("Authorization"))
+		if err != nil {
+			tracing.End(span, err)
+			if err.Code == http.StatusUnauthorized {
+				rw.Header().Set("WWW-Authenticate", "Bearer")
+			}
+			_ = problem.Write(rw, err)
 			return
 		}
+		span.End()
 
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+		next.ServeHTTP(rw, r.WithContext(NewContextWithClaims(r.Context(), claims)))
+	})
+}
 
-		claims, err := m.jwtParser.ParseToken(tokenString)
-		if err != nil {
-			http.Error(rw, fmt.Sprintf("invalid token: %v", err), http.StatusUnauthorized)
-			return
-		}
+// Authenticate verifies the bearer token of the Authorization header value and checks it grants
+// one of the required roles.
+func (m *JWTMiddleware) Authenticate(authHeader string) (jwt.MapClaims, *apperrors.AppError) {
+	if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
+		return nil, apperrors.NewUnauthorizedError("missing or invalid Authorization header")
+	}
 
-		roles, ok := claims["roles"].([]any)
-		if !ok || !m.hasRequiredRole(roles) {
-			http.Error(rw, "forbidden: insufficient permissions", http.StatusForbidden)
-			return
-		}
+	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 
-		ctx := context.WithValue(r.Context(), ctxKeyClaims, claims)
-		next.ServeHTTP(rw, r.WithContext(ctx))
-	})
+	claims, err := m.jwtParser.ParseToken(tokenString)
+	if err != nil {
+		return nil, apperrors.NewUnauthorizedError(fmt.Sprintf("invalid token: %v", err))
+	}
+
+	roles, ok := claims["roles"].([]any)
+	if !ok || !m.hasRequiredRole(roles) {
+		return nil, apperrors.NewForbiddenError("insufficient permissions")
+	}
+
+	return claims, nil
 }
 
 func (m *JWTMiddleware) hasRequiredRole(roles []any) bool {
@@ -62,3 +78,20 @@ func (m *JWTMiddleware) hasRequiredRole(roles []any) bool {
 	}
 	return false
 }
+
+// NewContextWithClaims returns a new context with verified JWT claims.
+func NewContextWithClaims(ctx context.Context, claims jwt.MapClaims) context.Context {
+	return context.WithValue(ctx, ctxKeyClaims, claims)
+}
+
+// GetClaimsFromContext extracts verified JWT claims from the context.
+func GetClaimsFromContext(ctx context.Context) jwt.MapClaims {
+	claims, _ := ctx.Value(ctxKeyClaims).(jwt.MapClaims)
+	return claims
+}
+
+// GetSubjectFromContext extracts the subject of the verified JWT from the context.
+func GetSubjectFromContext(ctx context.Context) string {
+	subject, _ := GetClaimsFromContext(ctx)["sub"].(string)
+	return subject
+}
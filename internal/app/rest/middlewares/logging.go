@@ -9,11 +9,17 @@ import (
 	"time"
 
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type ctxKey string
 
-const ctxKeyLogger ctxKey = "logger"
+const (
+	ctxKeyLogger    ctxKey = "logger"
+	ctxKeyRequestID ctxKey = "request_id"
+
+	HeaderRequestID = "X-Request-ID"
+)
 
 type LoggingMiddleware struct {
 	logger *logrus.Logger
@@ -30,20 +36,26 @@ func (h *LoggingMiddleware) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	startTime := time.Now()
+	requestID := generateOrExtractRequestID(r)
 
 	logger := h.logger.WithFields(
 		map[string]any{
-			"request_id":     generateOrExtractRequestID(r),
+			"request_id":     requestID,
 			"method":         r.Method,
 			"uri":            r.URL.RequestURI(),
 			"remote_addr":    r.RemoteAddr,
 			"content_length": r.ContentLength,
 		},
 	)
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		logger = logger.WithField("trace_id", spanContext.TraceID().String())
+	}
 
 	logger.Info("request started")
 
+	ctx = NewContextWithRequestID(ctx, requestID)
 	r = r.WithContext(NewContextWithLogger(ctx, logger))
+	rw.Header().Set(HeaderRequestID, requestID)
 	wrw := wrapResponseWriterIfNeeded(rw)
 
 	h.next.ServeHTTP(wrw, r)
@@ -60,13 +72,13 @@ func (h *LoggingMiddleware) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
 }
 
 func generateOrExtractRequestID(r *http.Request) string {
-	if requestID := r.Header.Get("X-Request-ID"); requestID != "" {
+	if requestID := r.Header.Get(HeaderRequestID); requestID != "" {
 		return requestID
 	}
-	return generateSecureRequestID()
+	return NewRequestID()
 }
 
-func generateSecureRequestID() string {
+func NewRequestID() string {
 	b := make([]byte, 16)
 	if _, err := rand.Read(b); err != nil {
 		panic("failed to generate random request ID")
@@ -88,6 +100,17 @@ func GetLoggerFromContext(ctx context.Context) logrus.FieldLogger {
 	return value.(logrus.FieldLogger)
 }
 
+// NewContextWithRequestID creates a new context with request ID.
+func NewContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, ctxKeyRequestID, requestID)
+}
+
+// GetRequestIDFromContext extracts request ID from the context.
+func GetRequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(ctxKeyRequestID).(string)
+	return requestID
+}
+
 func wrapResponseWriterIfNeeded(w http.ResponseWriter) *responseWriter {
 	return &responseWriter{ResponseWriter: w}
 }
@@ -116,6 +139,12 @@ func (rw *responseWriter) Write(buf []byte) (int, error) {
 	return n, err
 }
 
+// Unwrap exposes the wrapped writer to http.ResponseController, which streaming handlers
+// flush and set write deadlines through.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 func (rw *responseWriter) Status() int {
 	if !rw.wroteHeader {
 		return http.StatusOK
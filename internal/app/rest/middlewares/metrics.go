@@ -0,0 +1,51 @@
+package middlewares
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/faeelol/companies-store/internal/app/metrics"
+)
+
+// unmatchedRoute labels the requests no route matched, so unknown paths don't add label values.
+const unmatchedRoute = "unmatched"
+
+var (
+	httpRequests = promauto.With(metrics.Registry).NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests served.",
+	}, []string{"method", "route", "status"})
+	httpRequestDuration = promauto.With(metrics.Registry).NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Duration of the HTTP requests.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+)
+
+// NewMetricsMiddleware counts the requests and observes their duration, labelled by the route
+// pattern rather than the path, e.g. /api/companies_repo/v1/webhooks/{id}.
+func NewMetricsMiddleware() func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			startTime := time.Now()
+			wrw := wrapResponseWriterIfNeeded(rw)
+
+			next.ServeHTTP(wrw, r)
+
+			// the pattern is only known once the routing is done
+			route := unmatchedRoute
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				route = rctx.RoutePattern()
+			}
+
+			labels := prometheus.Labels{"method": r.Method, "route": route, "status": strconv.Itoa(wrw.Status())}
+			httpRequests.With(labels).Inc()
+			httpRequestDuration.With(labels).Observe(time.Since(startTime).Seconds())
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/faeelol/companies-store/internal/app/tracing"
+)
+
+// NewTracingMiddleware records every request as a server span, continuing the trace of the
+// traceparent header when there is one. The span is named after the route pattern, e.g.
+// "PATCH /api/companies_repo/v1/companies".
+func NewTracingMiddleware() func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			ctx, span := tracing.Tracer().Start(ctx, r.Method,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					semconv.HTTPRequestMethodKey.String(r.Method),
+					semconv.URLPath(r.URL.Path),
+				),
+			)
+			defer span.End()
+
+			wrw := wrapResponseWriterIfNeeded(rw)
+			next.ServeHTTP(wrw, r.WithContext(ctx))
+
+			// the pattern is only known once the routing is done
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				span.SetName(r.Method + " " + rctx.RoutePattern())
+				span.SetAttributes(semconv.HTTPRoute(rctx.RoutePattern()))
+			}
+			span.SetAttributes(semconv.HTTPResponseStatusCode(wrw.Status()))
+			if wrw.Status() >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(wrw.Status()))
+			}
+		})
+	}
+}
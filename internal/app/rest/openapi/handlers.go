@@ -0,0 +1,82 @@
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/faeelol/companies-store/internal/app/apperrors"
+	"github.com/faeelol/companies-store/internal/app/rest/problem"
+)
+
+//go:embed docs.html
+var docsPage []byte
+
+// SpecHandler serves the OpenAPI document. The document is built on the first request,
+// once all the routes have been registered.
+type SpecHandler struct {
+	build func() (*Document, error)
+
+	once sync.Once
+	spec []byte
+	err  error
+}
+
+func NewSpecHandler(build func() (*Document, error)) *SpecHandler {
+	return &SpecHandler{build: build}
+}
+
+func (h *SpecHandler) ServeHTTP(rw http.ResponseWriter, _ *http.Request) {
+	h.once.Do(func() {
+		var doc *Document
+		if doc, h.err = h.build(); h.err == nil {
+			h.spec, h.err = json.Marshal(doc)
+		}
+	})
+	if h.err != nil {
+		_ = problem.Write(rw, apperrors.NewInternalServerError("failed to build OpenAPI document").WithCause(h.err))
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	_, _ = rw.Write(h.spec)
+}
+
+func (h *SpecHandler) OpenAPIOperation() Operation {
+	return Operation{
+		Summary: "OpenAPI document of the API",
+		Tags:    []string{"docs"},
+		Responses: map[int]Response{
+			http.StatusOK: {
+				Description: "OpenAPI 3.1 document",
+				Content:     map[string]json.RawMessage{"application/json": json.RawMessage(`{"type": "object"}`)},
+			},
+		},
+	}
+}
+
+// DocsHandler serves the bundled page rendering the OpenAPI document served next to it.
+type DocsHandler struct{}
+
+func NewDocsHandler() *DocsHandler {
+	return &DocsHandler{}
+}
+
+func (h *DocsHandler) ServeHTTP(rw http.ResponseWriter, _ *http.Request) {
+	rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = rw.Write(docsPage)
+}
+
+func (h *DocsHandler) OpenAPIOperation() Operation {
+	return Operation{
+		Summary: "Interactive API documentation",
+		Tags:    []string{"docs"},
+		Responses: map[int]Response{
+			http.StatusOK: {
+				Description: "HTML page",
+				Content:     map[string]json.RawMessage{"text/html": json.RawMessage(`{"type": "string"}`)},
+			},
+		},
+	}
+}
@@ -0,0 +1,274 @@
+// Package openapi builds the OpenAPI document of the API from the registered chi routes.
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/faeelol/companies-store/internal/app/rest/problem"
+)
+
+const (
+	Version = "3.1.0"
+
+	bearerAuthScheme = "bearerAuth"
+	problemSchemaRef = `{"$ref": "#/components/schemas/Problem"}`
+)
+
+// Describer is implemented by handlers documenting the operation they serve.
+type Describer interface {
+	OpenAPIOperation() Operation
+}
+
+// Operation describes a single route. Secured operations require a JWT bearer token.
+type Operation struct {
+	Summary     string
+	Description string
+	Tags        []string
+	Secured     bool
+	Parameters  []Parameter
+	RequestBody *RequestBody
+	Responses   map[int]Response
+}
+
+type Parameter struct {
+	Name        string          `json:"name"`
+	In          string          `json:"in"`
+	Description string          `json:"description,omitempty"`
+	Required    bool            `json:"required,omitempty"`
+	Schema      json.RawMessage `json:"schema"`
+}
+
+// RequestBody maps media types to their schemas.
+type RequestBody struct {
+	Description string
+	Required    bool
+	Content     map[string]json.RawMessage
+}
+
+// Response maps media types to their schemas. Headers maps header names to their descriptions.
+type Response struct {
+	Description string
+	Headers     map[string]string
+	Content     map[string]json.RawMessage
+}
+
+func QueryParam(name, description, schema string) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: json.RawMessage(schema)}
+}
+
+func HeaderParam(name, description, schema string) Parameter {
+	return Parameter{Name: name, In: "header", Description: description, Schema: json.RawMessage(schema)}
+}
+
+func PathParam(name, description, schema string) Parameter {
+	return Parameter{Name: name, In: "path", Description: description, Required: true, Schema: json.RawMessage(schema)}
+}
+
+// Ref is the schema referencing a component schema.
+func Ref(name string) json.RawMessage {
+	return json.RawMessage(fmt.Sprintf(`{"$ref": "#/components/schemas/%s"}`, name))
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type Document struct {
+	OpenAPI    string                                `json:"openapi"`
+	Info       Info                                  `json:"info"`
+	Paths      map[string]map[string]operationObject `json:"paths"`
+	Components components                            `json:"components"`
+}
+
+type components struct {
+	Schemas         map[string]json.RawMessage `json:"schemas"`
+	SecuritySchemes map[string]securityScheme  `json:"securitySchemes"`
+}
+
+type securityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme"`
+	BearerFormat string `json:"bearerFormat"`
+}
+
+type operationObject struct {
+	Summary     string                    `json:"summary,omitempty"`
+	Description string                    `json:"description,omitempty"`
+	Tags        []string                  `json:"tags,omitempty"`
+	OperationID string                    `json:"operationId"`
+	Parameters  []Parameter               `json:"parameters,omitempty"`
+	RequestBody *requestBodyObject        `json:"requestBody,omitempty"`
+	Responses   map[string]responseObject `json:"responses"`
+	Security    []map[string][]string     `json:"security,omitempty"`
+}
+
+type requestBodyObject struct {
+	Description string                     `json:"description,omitempty"`
+	Required    bool                       `json:"required,omitempty"`
+	Content     map[string]mediaTypeObject `json:"content"`
+}
+
+type responseObject struct {
+	Description string                     `json:"description"`
+	Headers     map[string]headerObject    `json:"headers,omitempty"`
+	Content     map[string]mediaTypeObject `json:"content,omitempty"`
+}
+
+type headerObject struct {
+	Description string          `json:"description,omitempty"`
+	Schema      json.RawMessage `json:"schema"`
+}
+
+type mediaTypeObject struct {
+	Schema json.RawMessage `json:"schema"`
+}
+
+// pathParamRe matches chi path params, optionally restricted with a regexp, e.g. {id} or {id:[0-9]+}.
+var pathParamRe = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?}`)
+
+// Options of the document. Schemas become the component schemas the operations can reference,
+// a Problem schema is expected among them. Only the routes under BasePath are documented, and
+// BasePath is left out of the operation ids.
+type Options struct {
+	Info     Info
+	BasePath string
+	Schemas  map[string]json.RawMessage
+}
+
+// Build walks the routes and documents every one of them.
+func Build(routes chi.Routes, opts Options) (*Document, error) {
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    opts.Info,
+		Paths:   map[string]map[string]operationObject{},
+		Components: components{
+			Schemas: opts.Schemas,
+			SecuritySchemes: map[string]securityScheme{
+				bearerAuthScheme: {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			},
+		},
+	}
+
+	err := chi.Walk(routes, func(method, route string, handler http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if !strings.HasPrefix(route, opts.BasePath) {
+			return nil
+		}
+
+		var op Operation
+		if d, ok := handler.(Describer); ok {
+			op = d.OpenAPIOperation()
+		}
+
+		path := pathParamRe.ReplaceAllString(route, "{$1}")
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = map[string]operationObject{}
+		}
+		doc.Paths[path][strings.ToLower(method)] = buildOperation(method, path, opts.BasePath, op)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+func buildOperation(method, path, basePath string, op Operation) operationObject {
+	obj := operationObject{
+		Summary:     op.Summary,
+		Description: op.Description,
+		Tags:        op.Tags,
+		OperationID: operationID(method, strings.TrimPrefix(path, basePath)),
+		Parameters:  withPathParams(path, op.Parameters),
+		Responses:   map[string]responseObject{},
+	}
+
+	if op.RequestBody != nil {
+		obj.RequestBody = &requestBodyObject{
+			Description: op.RequestBody.Description,
+			Required:    op.RequestBody.Required,
+			Content:     mediaTypes(op.RequestBody.Content),
+		}
+	}
+
+	for status, resp := range op.Responses {
+		obj.Responses[strconv.Itoa(status)] = buildResponse(resp)
+	}
+	if op.Secured {
+		obj.Security = []map[string][]string{{bearerAuthScheme: {}}}
+		obj.Responses[strconv.Itoa(http.StatusUnauthorized)] = problemResponse("Missing or invalid token")
+		obj.Responses[strconv.Itoa(http.StatusForbidden)] = problemResponse("Insufficient permissions")
+	}
+	obj.Responses["default"] = problemResponse("Error")
+
+	return obj
+}
+
+func buildResponse(resp Response) responseObject {
+	obj := responseObject{
+		Description: resp.Description,
+		Content:     mediaTypes(resp.Content),
+	}
+	if len(resp.Headers) > 0 {
+		obj.Headers = make(map[string]headerObject, len(resp.Headers))
+		for name, description := range resp.Headers {
+			obj.Headers[name] = headerObject{Description: description, Schema: json.RawMessage(`{"type": "string"}`)}
+		}
+	}
+	return obj
+}
+
+func problemResponse(description string) responseObject {
+	return responseObject{
+		Description: description,
+		Content: map[string]mediaTypeObject{
+			problem.ContentType: {Schema: json.RawMessage(problemSchemaRef)},
+		},
+	}
+}
+
+func mediaTypes(content map[string]json.RawMessage) map[string]mediaTypeObject {
+	if len(content) == 0 {
+		return nil
+	}
+	res := make(map[string]mediaTypeObject, len(content))
+	for mediaType, schema := range content {
+		res[mediaType] = mediaTypeObject{Schema: schema}
+	}
+	return res
+}
+
+// withPathParams adds the path params the operation doesn't describe itself.
+func withPathParams(path string, params []Parameter) []Parameter {
+	res := append([]Parameter(nil), params...)
+	for _, match := range pathParamRe.FindAllStringSubmatch(path, -1) {
+		described := false
+		for _, p := range params {
+			if p.In == "path" && p.Name == match[1] {
+				described = true
+				break
+			}
+		}
+		if !described {
+			res = append(res, PathParam(match[1], "", `{"type": "string"}`))
+		}
+	}
+	return res
+}
+
+// operationID derives a stable id from the method and path, e.g. get_companies_id_revisions.
+func operationID(method, path string) string {
+	parts := strings.FieldsFunc(path, func(r rune) bool {
+		return r == '/' || r == '{' || r == '}' || r == ':' || r == '.' || r == '-'
+	})
+	return strings.ToLower(method) + "_" + strings.Join(parts, "_")
+}
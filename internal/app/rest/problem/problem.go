@@ -0,0 +1,48 @@
+// Package problem writes error responses as RFC 7807 problem details.
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/faeelol/companies-store/internal/app/apperrors"
+)
+
+const ContentType = "application/problem+json"
+
+// Details is an RFC 7807 problem details object. Code and Errors are extension members
+// carrying the machine-readable error code and the per-field errors.
+type Details struct {
+	Type   string                 `json:"type"`
+	Title  string                 `json:"title"`
+	Status int                    `json:"status"`
+	Detail string                 `json:"detail,omitempty"`
+	Code   string                 `json:"code"`
+	Errors []apperrors.FieldError `json:"errors,omitempty"`
+}
+
+// FromAppError builds problem details for the application error.
+func FromAppError(err *apperrors.AppError) Details {
+	return Details{
+		Type:   "about:blank",
+		Title:  http.StatusText(err.Code),
+		Status: err.Code,
+		Detail: err.Message,
+		Code:   err.ErrorCode,
+		Errors: err.Details,
+	}
+}
+
+// Write responds with the problem details of the application error.
+func Write(rw http.ResponseWriter, err *apperrors.AppError) error {
+	body, marshalErr := json.Marshal(FromAppError(err))
+	if marshalErr != nil {
+		rw.WriteHeader(http.StatusInternalServerError)
+		return marshalErr
+	}
+
+	rw.Header().Set("Content-Type", ContentType)
+	rw.WriteHeader(err.Code)
+	_, writeErr := rw.Write(body)
+	return writeErr
+}
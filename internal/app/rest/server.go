@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -13,61 +14,145 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/faeelol/companies-store/internal/app/database/repositories"
-	"github.com/faeelol/companies-store/internal/app/kafka"
+	"github.com/faeelol/companies-store/internal/app/events"
+	"github.com/faeelol/companies-store/internal/app/health"
 	"github.com/faeelol/companies-store/internal/app/logic/companies"
+	"github.com/faeelol/companies-store/internal/app/logic/idempotency"
+	"github.com/faeelol/companies-store/internal/app/logic/stream"
+	"github.com/faeelol/companies-store/internal/app/logic/webhooks"
 	"github.com/faeelol/companies-store/internal/app/rest/handlers"
 	"github.com/faeelol/companies-store/internal/app/rest/jwt"
 	"github.com/faeelol/companies-store/internal/app/rest/middlewares"
+	"github.com/faeelol/companies-store/internal/app/rest/openapi"
 )
 
+const apiVersion = "1.0.0"
+
 type Server struct {
 	cfg        *Config
 	httpServer *http.Server
+	broker     *stream.Broker
+	// inFlight counts the requests being served, for the shutdown summary.
+	inFlight atomic.Int64
 }
 
-func NewServer(cfg *Config, logger *logrus.Logger, db *sqlx.DB, producer *kafka.Producer) *Server {
+func NewServer(
+	cfg *Config,
+	logger *logrus.Logger,
+	db *sqlx.DB,
+	eventBuilder *events.Builder,
+	broker *stream.Broker,
+	checker *health.Checker,
+) *Server {
 	jwtParser := jwt.NewJWTParser(*cfg.JWT)
 
-	companiesController := companies.NewCompaniesController(db, repositories.NewCompanyRepository(), producer)
+	companiesController := companies.NewCompaniesController(
+		db,
+		repositories.NewCompanyRepository(),
+		repositories.NewRevisionRepository(),
+		repositories.NewOutboxRepository(),
+		repositories.NewWebhookRepository(),
+		eventBuilder,
+		broker,
+	)
+	webhooksController := webhooks.NewWebhooksController(db, repositories.NewWebhookRepository())
+
+	idempotencyStore := idempotency.NewStore(db, repositories.NewIdempotencyRepository(), cfg.IdempotencyTTL, cfg.IdempotencyLockTimeout)
 
-	routes := createRoutingTable(logger, jwtParser, companiesController)
+	routes := createRoutingTable(logger, jwtParser, companiesController, webhooksController, broker, idempotencyStore, checker)
 
-	httpServer := &http.Server{
+	s := &Server{cfg: cfg, broker: broker}
+	s.httpServer = &http.Server{
 		Addr:              cfg.Addr,
 		WriteTimeout:      cfg.WriteTimeout,
 		ReadTimeout:       cfg.ReadTimeout,
 		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
 		IdleTimeout:       cfg.IdleTimeout,
-		Handler:           routes,
+		Handler:           s.countInFlight(routes),
 	}
 
-	return &Server{cfg: cfg, httpServer: httpServer}
+	return s
+}
+
+func (s *Server) countInFlight(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		s.inFlight.Add(1)
+		defer s.inFlight.Add(-1)
+		next.ServeHTTP(rw, r)
+	})
 }
 
 func createRoutingTable(
 	logger *logrus.Logger,
 	jwtParser *jwt.Parser,
 	companiesController *companies.Controller,
+	webhooksController *webhooks.Controller,
+	broker *stream.Broker,
+	idempotencyStore middlewares.IdempotencyStore,
+	checker *health.Checker,
 ) chi.Router {
 	r := chi.NewRouter()
+	root := r
+	r.Use(middlewares.NewTracingMiddleware())
 	r.Use(middlewares.NewErrorHandlerMiddleware(logger))
 	r.Use(middlewares.NewLoggingMiddleware(logger))
+	r.Use(middlewares.NewMetricsMiddleware())
 
 	authAdminMiddleware := middlewares.NewJWTMiddleware(jwtParser, []string{"admin"})
+	idempotencyMiddleware := middlewares.NewIdempotencyMiddleware(idempotencyStore)
 
-	r.Route("/api/companies_repo/v1", func(r chi.Router) {
+	r.Method(http.MethodGet, "/healthz", checker.LivenessHandler())
+	r.Method(http.MethodGet, "/readyz", checker.ReadinessHandler())
+
+	r.Route(handlers.APIBasePath, func(r chi.Router) {
+		r.Method(http.MethodGet, "/openapi.json", openapi.NewSpecHandler(func() (*openapi.Document, error) {
+			return buildOpenAPIDocument(root)
+		}))
+		r.Method(http.MethodGet, "/docs", openapi.NewDocsHandler())
 		r.Method(http.MethodGet, "/companies", handlers.NewGetCompaniesHandler(companiesController))
+		r.Method(http.MethodGet, "/companies/search", handlers.NewSearchCompaniesHandler(companiesController))
+		r.Method(http.MethodGet, "/companies/events", handlers.NewCompanyEventsHandler(broker))
+		r.Method(http.MethodGet, "/events/schemas", handlers.NewListEventSchemasHandler())
+		r.Method(http.MethodGet, "/events/schemas/{name}", handlers.NewGetEventSchemaHandler())
 		r.Group(func(r chi.Router) {
 			r.Use(authAdminMiddleware.VerifyToken)
+			r.Use(idempotencyMiddleware.Handle)
 			r.Method(http.MethodPost, "/companies", handlers.NewCreateCompaniesHandler(companiesController))
+			r.Method(http.MethodPost, "/companies/bulk", handlers.NewBulkCreateCompaniesHandler(companiesController))
 			r.Method(http.MethodDelete, "/companies", handlers.NewDeleteCompaniesHandler(companiesController))
 			r.Method(http.MethodPatch, "/companies", handlers.NewPatchCompaniesHandler(companiesController))
+			r.Method(http.MethodPost, "/companies/{id}:restore", handlers.NewRestoreCompaniesHandler(companiesController))
+			r.Method(http.MethodGet, "/companies/{id}/revisions", handlers.NewListRevisionsHandler(companiesController))
+			r.Method(http.MethodPost, "/webhooks", handlers.NewCreateWebhookHandler(webhooksController))
+			r.Method(http.MethodGet, "/webhooks", handlers.NewListWebhooksHandler(webhooksController))
+			r.Method(http.MethodGet, "/webhooks/{id}", handlers.NewGetWebhookHandler(webhooksController))
+			r.Method(http.MethodPatch, "/webhooks/{id}", handlers.NewPatchWebhookHandler(webhooksController))
+			r.Method(http.MethodDelete, "/webhooks/{id}", handlers.NewDeleteWebhookHandler(webhooksController))
+			r.Method(http.MethodGet, "/webhooks/{id}/deliveries", handlers.NewListWebhookDeliveriesHandler(webhooksController))
 		})
 	})
 	return r
 }
 
-func (s Server) Start(ctx context.Context, logger *logrus.Logger) error {
+func buildOpenAPIDocument(routes chi.Routes) (*openapi.Document, error) {
+	return openapi.Build(routes, openapi.Options{
+		Info: openapi.Info{
+			Title:   "Companies Store API",
+			Version: apiVersion,
+		},
+		BasePath: handlers.APIBasePath,
+		Schemas:  handlers.OpenAPISchemas(),
+	})
+}
+
+// OpenAPIDocument builds the OpenAPI document of the routes the server serves.
+func OpenAPIDocument() (*openapi.Document, error) {
+	// handlers only keep their dependencies, so none are needed to describe them
+	routes := createRoutingTable(logrus.New(), nil, nil, nil, nil, nil, nil)
+	return buildOpenAPIDocument(routes)
+}
+
+func (s *Server) Start(ctx context.Context, logger *logrus.Logger) error {
 	errChan := make(chan error, 1)
 
 	go func() {
@@ -80,16 +165,42 @@ func (s Server) Start(ctx context.Context, logger *logrus.Logger) error {
 
 	select {
 	case <-ctx.Done():
-		logger.Info("shutting down HTTP service...")
-		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-
-		if err := s.httpServer.Shutdown(shutdownCtx); err != nil {
-			return fmt.Errorf("error shutting down server: %w", err)
-		}
-		return nil
-
+		return s.shutdown(logger)
 	case err := <-errChan:
 		return err
 	}
 }
+
+// shutdown stops accepting connections and waits for the in-flight requests up to the shutdown
+// timeout, aborting the remaining ones.
+func (s *Server) shutdown(logger *logrus.Logger) error {
+	start := time.Now()
+	inFlight := s.inFlight.Load()
+	logger.WithField("in_flight", inFlight).Info("shutting down HTTP service...")
+
+	// event streams never end on their own, the shutdown would wait for them until it times out
+	streams := s.broker.Close()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.cfg.ShutdownTimeout)
+	defer cancel()
+
+	err := s.httpServer.Shutdown(shutdownCtx)
+	aborted := s.inFlight.Load()
+	if errors.Is(err, context.DeadlineExceeded) {
+		// the handlers of the aborted requests see their context canceled
+		err = s.httpServer.Close()
+	}
+
+	logger.WithFields(logrus.Fields{
+		"in_flight": inFlight,
+		"drained":   inFlight - aborted,
+		"aborted":   aborted,
+		"streams":   streams,
+		"duration":  time.Since(start).String(),
+	}).Info("HTTP service stopped")
+
+	if err != nil {
+		return fmt.Errorf("error shutting down server: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,246 @@
+package sinks
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const natsDefaultPort = "4222"
+
+// NATSSink publishes the messages to a NATS subject, speaking the NATS client protocol over TCP.
+// The message headers are sent as NATS headers, along with the key in X-Event-Key. Every publish
+// waits for the server to acknowledge it with a PONG, and a broken connection is reopened on the
+// next publish. Tombstones are skipped.
+type NATSSink struct {
+	addr     string
+	subject  string
+	timeout  time.Duration
+	user     string
+	password string
+	token    string
+	// dial opens the connection to the server
+	dial func(ctx context.Context) (net.Conn, error)
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+type natsInfo struct {
+	Headers bool `json:"headers"`
+}
+
+type natsConnect struct {
+	Verbose  bool   `json:"verbose"`
+	Pedantic bool   `json:"pedantic"`
+	Headers  bool   `json:"headers"`
+	Name     string `json:"name"`
+	Lang     string `json:"lang"`
+	Version  string `json:"version"`
+	User     string `json:"user,omitempty"`
+	Password string `json:"pass,omitempty"`
+	Token    string `json:"auth_token,omitempty"`
+}
+
+func NewNATSSink(rawURL, subject string, timeout time.Duration) (*NATSSink, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url: %w", err)
+	}
+	if u.Scheme != "nats" {
+		return nil, fmt.Errorf("unsupported url scheme %q", u.Scheme)
+	}
+	if strings.ContainsAny(subject, " \t\r\n") {
+		return nil, fmt.Errorf("invalid subject %q", subject)
+	}
+
+	s := &NATSSink{
+		addr:    u.Host,
+		subject: subject,
+		timeout: timeout,
+	}
+	if u.Port() == "" {
+		s.addr = net.JoinHostPort(u.Hostname(), natsDefaultPort)
+	}
+	if u.User != nil {
+		if password, ok := u.User.Password(); ok {
+			s.user, s.password = u.User.Username(), password
+		} else {
+			s.token = u.User.Username()
+		}
+	}
+	s.dial = func(ctx context.Context) (net.Conn, error) {
+		dialer := net.Dialer{Timeout: s.timeout}
+		return dialer.DialContext(ctx, "tcp", s.addr)
+	}
+	return s, nil
+}
+
+func (s *NATSSink) Publish(ctx context.Context, key string, value []byte, headers map[string]string) error {
+	if value == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		if err := s.connect(ctx); err != nil {
+			return err
+		}
+	}
+
+	err := s.publish(ctx, key, value, headers)
+	if err != nil {
+		s.closeConn()
+	}
+	return err
+}
+
+func (s *NATSSink) connect(ctx context.Context) error {
+	conn, err := s.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+	s.conn = conn
+	s.reader = bufio.NewReader(conn)
+	s.setDeadline(ctx)
+
+	line, err := s.readLine()
+	if err != nil {
+		s.closeConn()
+		return fmt.Errorf("failed to read NATS server info: %w", err)
+	}
+	rawInfo, ok := strings.CutPrefix(line, "INFO ")
+	if !ok {
+		s.closeConn()
+		return fmt.Errorf("unexpected NATS greeting %q", line)
+	}
+	var info natsInfo
+	if err := json.Unmarshal([]byte(rawInfo), &info); err != nil {
+		s.closeConn()
+		return fmt.Errorf("failed to parse NATS server info: %w", err)
+	}
+	if !info.Headers {
+		s.closeConn()
+		return errors.New("NATS server doesn't support headers")
+	}
+
+	connect, err := json.Marshal(natsConnect{
+		Headers:  true,
+		Name:     "companies-store",
+		Lang:     "go",
+		Version:  "1.0.0",
+		User:     s.user,
+		Password: s.password,
+		Token:    s.token,
+	})
+	if err != nil {
+		s.closeConn()
+		return err
+	}
+	if _, err := fmt.Fprintf(s.conn, "CONNECT %s\r\nPING\r\n", connect); err != nil {
+		s.closeConn()
+		return fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+	if err := s.waitPong(); err != nil {
+		s.closeConn()
+		return fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+	return nil
+}
+
+func (s *NATSSink) publish(ctx context.Context, key string, value []byte, headers map[string]string) error {
+	s.setDeadline(ctx)
+
+	var hdr strings.Builder
+	hdr.WriteString("NATS/1.0\r\n")
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&hdr, "%s: %s\r\n", name, sanitizeHeaderValue(headers[name]))
+	}
+	fmt.Fprintf(&hdr, "%s: %s\r\n\r\n", HeaderEventKey, sanitizeHeaderValue(key))
+
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "HPUB %s %d %d\r\n", s.subject, hdr.Len(), hdr.Len()+len(value))
+	buf.WriteString(hdr.String())
+	buf.Write(value)
+	buf.WriteString("\r\nPING\r\n")
+
+	if _, err := s.conn.Write([]byte(buf.String())); err != nil {
+		return fmt.Errorf("failed to publish to NATS: %w", err)
+	}
+	if err := s.waitPong(); err != nil {
+		return fmt.Errorf("failed to publish to NATS: %w", err)
+	}
+	return nil
+}
+
+// waitPong reads until the PONG answering our PING, answering the pings of the server.
+func (s *NATSSink) waitPong() error {
+	for {
+		line, err := s.readLine()
+		if err != nil {
+			return err
+		}
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := s.conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("NATS error: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+		// +OK and INFO updates need no answer
+	}
+}
+
+func (s *NATSSink) readLine() (string, error) {
+	line, err := s.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (s *NATSSink) setDeadline(ctx context.Context) {
+	deadline := time.Now().Add(s.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	_ = s.conn.SetDeadline(deadline)
+}
+
+func (s *NATSSink) closeConn() {
+	if s.conn != nil {
+		_ = s.conn.Close()
+		s.conn = nil
+		s.reader = nil
+	}
+}
+
+func (s *NATSSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closeConn()
+	return nil
+}
+
+func sanitizeHeaderValue(v string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(v)
+}
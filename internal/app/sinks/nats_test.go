@@ -0,0 +1,244 @@
+package sinks
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeNATSConn is the server end of a connection opened by the sink.
+type fakeNATSConn struct {
+	t      *testing.T
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// newPipeNATSSink returns a sink whose connections are pipes, their server ends being sent on the
+// returned channel as they're opened.
+func newPipeNATSSink(t *testing.T) (*NATSSink, <-chan *fakeNATSConn) {
+	t.Helper()
+
+	sink, err := NewNATSSink("nats://localhost", "companies.events", time.Second)
+	if err != nil {
+		t.Fatalf("NewNATSSink() error = %v", err)
+	}
+	t.Cleanup(func() {
+		_ = sink.Close()
+	})
+
+	conns := make(chan *fakeNATSConn, 4)
+	sink.dial = func(context.Context) (net.Conn, error) {
+		client, server := net.Pipe()
+		_ = server.SetDeadline(time.Now().Add(5 * time.Second))
+		t.Cleanup(func() {
+			_ = server.Close()
+		})
+		conns <- &fakeNATSConn{t: t, conn: server, reader: bufio.NewReader(server)}
+		return client, nil
+	}
+	return sink, conns
+}
+
+func (c *fakeNATSConn) send(line string) {
+	c.t.Helper()
+	if _, err := io.WriteString(c.conn, line+"\r\n"); err != nil {
+		c.t.Fatalf("failed to send %q: %v", line, err)
+	}
+}
+
+func (c *fakeNATSConn) expect(want string) {
+	c.t.Helper()
+	if line := c.readLine(); line != want {
+		c.t.Fatalf("read %q, want %q", line, want)
+	}
+}
+
+func (c *fakeNATSConn) readLine() string {
+	c.t.Helper()
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		c.t.Fatalf("failed to read: %v", err)
+	}
+	return strings.TrimSuffix(line, "\r\n")
+}
+
+// handshake greets the sink with a server supporting headers and accepts its CONNECT.
+func (c *fakeNATSConn) handshake() {
+	c.t.Helper()
+	c.send(`INFO {"server_id":"fake","headers":true}`)
+	if line := c.readLine(); !strings.HasPrefix(line, "CONNECT {") || !strings.Contains(line, `"headers":true`) {
+		c.t.Fatalf("read %q, want a CONNECT with headers", line)
+	}
+	c.expect("PING")
+	c.send("PONG")
+}
+
+// readHPUB reads a HPUB message and its PING, and returns the header block and the payload.
+func (c *fakeNATSConn) readHPUB() (header, payload string) {
+	c.t.Helper()
+	var subject string
+	var headerLen, totalLen int
+	line := c.readLine()
+	if _, err := fmt.Sscanf(line, "HPUB %s %d %d", &subject, &headerLen, &totalLen); err != nil {
+		c.t.Fatalf("read %q, want a HPUB: %v", line, err)
+	}
+	if subject != "companies.events" {
+		c.t.Errorf("subject = %q, want companies.events", subject)
+	}
+
+	message := make([]byte, totalLen+2)
+	if _, err := io.ReadFull(c.reader, message); err != nil {
+		c.t.Fatalf("failed to read the message of %d bytes: %v", totalLen, err)
+	}
+	if !strings.HasSuffix(string(message), "\r\n") {
+		c.t.Fatalf("message %q doesn't end where its byte count says", message)
+	}
+	c.expect("PING")
+	return string(message[:headerLen]), string(message[headerLen:totalLen])
+}
+
+// publish runs Publish in the background, the test playing the server meanwhile.
+func publish(sink *NATSSink, key, value string, headers map[string]string) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		done <- sink.Publish(context.Background(), key, []byte(value), headers)
+	}()
+	return done
+}
+
+func waitPublish(t *testing.T, done <-chan error) error {
+	t.Helper()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("Publish didn't return")
+		return nil
+	}
+}
+
+func TestNATSSinkRejectsServerWithoutHeaders(t *testing.T) {
+	sink, conns := newPipeNATSSink(t)
+
+	done := publish(sink, "key", "{}", nil)
+	server := <-conns
+	server.send(`INFO {"server_id":"fake","headers":false}`)
+
+	err := waitPublish(t, done)
+	if err == nil || !strings.Contains(err.Error(), "doesn't support headers") {
+		t.Fatalf("Publish() error = %v, want headers not supported", err)
+	}
+	if _, err := server.reader.ReadString('\n'); err == nil {
+		t.Error("connection still open after the failed handshake")
+	}
+}
+
+func TestNATSSinkReportsServerErrors(t *testing.T) {
+	sink, conns := newPipeNATSSink(t)
+
+	done := publish(sink, "key", "{}", nil)
+	server := <-conns
+	server.send(`INFO {"server_id":"fake","headers":true}`)
+	server.readLine()
+	server.expect("PING")
+	server.send("-ERR 'Authorization Violation'")
+
+	err := waitPublish(t, done)
+	if err == nil || !strings.Contains(err.Error(), "NATS error: 'Authorization Violation'") {
+		t.Fatalf("Publish() error = %v, want the authorization violation", err)
+	}
+
+	done = publish(sink, "key", "{}", nil)
+	server = <-conns
+	server.handshake()
+	server.readHPUB()
+	server.send("-ERR 'Maximum Payload Violation'")
+
+	err = waitPublish(t, done)
+	if err == nil || !strings.Contains(err.Error(), "NATS error: 'Maximum Payload Violation'") {
+		t.Fatalf("Publish() error = %v, want the payload violation", err)
+	}
+}
+
+func TestNATSSinkHPUBByteCounts(t *testing.T) {
+	sink, conns := newPipeNATSSink(t)
+
+	value := `{"name":"Société Générale"}`
+	done := publish(sink, "01935e9a-key", value, map[string]string{
+		"x-request-id": "req\r\n1",
+		"content-type": "application/cloudevents+json",
+	})
+	server := <-conns
+	server.handshake()
+	header, payload := server.readHPUB()
+	// the server pings while the sink waits for its PONG
+	server.send("PING")
+	server.expect("PONG")
+	server.send("PONG")
+
+	if err := waitPublish(t, done); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	wantHeader := "NATS/1.0\r\n" +
+		"content-type: application/cloudevents+json\r\n" +
+		"x-request-id: req  1\r\n" +
+		HeaderEventKey + ": 01935e9a-key\r\n\r\n"
+	if header != wantHeader {
+		t.Errorf("header = %q, want %q", header, wantHeader)
+	}
+	if payload != value {
+		t.Errorf("payload = %q, want %q", payload, value)
+	}
+}
+
+func TestNATSSinkReconnectsAfterWriteError(t *testing.T) {
+	sink, conns := newPipeNATSSink(t)
+
+	done := publish(sink, "key", "first", nil)
+	server := <-conns
+	server.handshake()
+	server.readHPUB()
+	server.send("PONG")
+	if err := waitPublish(t, done); err != nil {
+		t.Fatalf("first Publish() error = %v", err)
+	}
+
+	_ = server.conn.Close()
+	if err := waitPublish(t, publish(sink, "key", "second", nil)); err == nil {
+		t.Fatal("Publish() on a closed connection succeeded")
+	}
+
+	done = publish(sink, "key", "third", nil)
+	select {
+	case server = <-conns:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the sink didn't reconnect")
+	}
+	server.handshake()
+	if _, payload := server.readHPUB(); payload != "third" {
+		t.Errorf("payload = %q, want third", payload)
+	}
+	server.send("PONG")
+	if err := waitPublish(t, done); err != nil {
+		t.Fatalf("Publish() after reconnecting error = %v", err)
+	}
+}
+
+func TestNATSSinkSkipsTombstones(t *testing.T) {
+	sink, conns := newPipeNATSSink(t)
+
+	if err := sink.Publish(context.Background(), "key", nil, nil); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	select {
+	case <-conns:
+		t.Error("the sink connected to publish a tombstone")
+	default:
+	}
+}
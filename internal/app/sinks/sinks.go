@@ -0,0 +1,136 @@
+// Package sinks provides the destinations the outbox relay publishes the events to.
+package sinks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/faeelol/companies-store/internal/app/kafka"
+)
+
+// Sink publishes messages. A nil value is a tombstone, the sinks that have no use for
+// tombstones skip them.
+type Sink interface {
+	Publish(ctx context.Context, key string, value []byte, headers map[string]string) error
+	Close() error
+}
+
+// New builds the configured sinks, behind a fan-out publishing to all of them.
+func New(cfg *Config, kafkaCfg *kafka.Config, logger logrus.FieldLogger) (*Fanout, error) {
+	if len(cfg.Sinks) == 0 {
+		return nil, errors.New("no event sinks configured")
+	}
+
+	fanout := &Fanout{logger: logger}
+	for i, sinkCfg := range cfg.Sinks {
+		if sinkCfg.OnFailure != OnFailureFail && sinkCfg.OnFailure != OnFailureIgnore {
+			_ = fanout.Close()
+			return nil, fmt.Errorf("sink %d: unknown failure policy %q", i, sinkCfg.OnFailure)
+		}
+
+		sink, err := newSink(sinkCfg, kafkaCfg, logger)
+		if err != nil {
+			_ = fanout.Close()
+			return nil, fmt.Errorf("sink %d (%s): %w", i, sinkCfg.Type, err)
+		}
+		fanout.targets = append(fanout.targets, fanoutTarget{
+			name:      fmt.Sprintf("%d-%s", i, sinkCfg.Type),
+			sink:      sink,
+			onFailure: sinkCfg.OnFailure,
+		})
+	}
+
+	return fanout, nil
+}
+
+func newSink(cfg SinkConfig, kafkaCfg *kafka.Config, logger logrus.FieldLogger) (Sink, error) {
+	switch cfg.Type {
+	case TypeKafka:
+		if cfg.Topic != "" {
+			return kafka.NewTopicProducer(kafkaCfg, cfg.Topic), nil
+		}
+		return kafka.NewProducer(kafkaCfg), nil
+	case TypeStdout:
+		return NewWriterSink(os.Stdout), nil
+	case TypeLog:
+		return NewLogSink(logger), nil
+	case TypeFile:
+		if cfg.Path == "" {
+			return nil, errors.New("path is required")
+		}
+		return NewFileSink(cfg.Path, cfg.Fsync)
+	case TypeWebhook:
+		if cfg.URL == "" {
+			return nil, errors.New("url is required")
+		}
+		return NewWebhookSink(cfg.URL, cfg.Headers, cfg.Timeout), nil
+	case TypeNATS:
+		if cfg.URL == "" || cfg.Subject == "" {
+			return nil, errors.New("url and subject are required")
+		}
+		return NewNATSSink(cfg.URL, cfg.Subject, cfg.Timeout)
+	case TypeNoop:
+		return Noop{}, nil
+	default:
+		return nil, fmt.Errorf("unknown sink type %q", cfg.Type)
+	}
+}
+
+type fanoutTarget struct {
+	name      string
+	sink      Sink
+	onFailure string
+}
+
+// Fanout publishes every message to all its sinks. The publish fails when a sink with the fail
+// policy fails; the relay then publishes the message again to all the sinks, so they all get it
+// at least once.
+type Fanout struct {
+	targets []fanoutTarget
+	logger  logrus.FieldLogger
+}
+
+func (f *Fanout) Publish(ctx context.Context, key string, value []byte, headers map[string]string) error {
+	var errs []error
+	for _, target := range f.targets {
+		err := target.sink.Publish(ctx, key, value, headers)
+		if err == nil {
+			continue
+		}
+		if target.onFailure == OnFailureIgnore {
+			f.logger.WithFields(logrus.Fields{
+				"error": err,
+				"sink":  target.name,
+				"key":   key,
+			}).Warn("failed to publish to sink, message dropped for the sink")
+			continue
+		}
+		errs = append(errs, fmt.Errorf("sink %s: %w", target.name, err))
+	}
+	return errors.Join(errs...)
+}
+
+func (f *Fanout) Close() error {
+	var errs []error
+	for _, target := range f.targets {
+		if err := target.sink.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("sink %s: %w", target.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Noop drops the messages.
+type Noop struct{}
+
+func (Noop) Publish(context.Context, string, []byte, map[string]string) error {
+	return nil
+}
+
+func (Noop) Close() error {
+	return nil
+}
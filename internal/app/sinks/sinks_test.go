@@ -0,0 +1,102 @@
+package sinks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+type stubSink struct {
+	err       error
+	published []string
+}
+
+func (s *stubSink) Publish(_ context.Context, key string, _ []byte, _ map[string]string) error {
+	s.published = append(s.published, key)
+	return s.err
+}
+
+func (s *stubSink) Close() error {
+	return nil
+}
+
+func TestFanoutFailurePolicies(t *testing.T) {
+	errDown := errors.New("sink down")
+
+	tests := []struct {
+		name      string
+		onFailure []string
+		errs      []error
+		wantErr   []string
+	}{
+		{
+			name:      "all sinks succeed",
+			onFailure: []string{OnFailureFail, OnFailureIgnore},
+			errs:      []error{nil, nil},
+		},
+		{
+			name:      "a failing sink with the fail policy fails the publish",
+			onFailure: []string{OnFailureFail, OnFailureFail},
+			errs:      []error{nil, errDown},
+			wantErr:   []string{"sink 1-stub: sink down"},
+		},
+		{
+			name:      "a failing sink with the ignore policy is skipped",
+			onFailure: []string{OnFailureIgnore, OnFailureFail},
+			errs:      []error{errDown, nil},
+		},
+		{
+			name:      "every failing sink with the fail policy is reported",
+			onFailure: []string{OnFailureFail, OnFailureIgnore, OnFailureFail},
+			errs:      []error{errDown, errDown, errDown},
+			wantErr:   []string{"sink 0-stub: sink down", "sink 2-stub: sink down"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := logrus.New()
+			logger.SetOutput(io.Discard)
+
+			fanout := &Fanout{logger: logger}
+			var stubs []*stubSink
+			for i, onFailure := range tt.onFailure {
+				stub := &stubSink{err: tt.errs[i]}
+				stubs = append(stubs, stub)
+				fanout.targets = append(fanout.targets, fanoutTarget{
+					name:      fmt.Sprintf("%d-stub", i),
+					sink:      stub,
+					onFailure: onFailure,
+				})
+			}
+
+			err := fanout.Publish(context.Background(), "key", []byte("{}"), nil)
+			if len(tt.wantErr) == 0 && err != nil {
+				t.Fatalf("Publish() error = %v, want none", err)
+			}
+			if len(tt.wantErr) > 0 {
+				if err == nil {
+					t.Fatalf("Publish() error = nil, want %v", tt.wantErr)
+				}
+				if !errors.Is(err, errDown) {
+					t.Errorf("Publish() error = %v, doesn't wrap the sink error", err)
+				}
+				if got := strings.Split(err.Error(), "\n"); strings.Join(got, "|") != strings.Join(tt.wantErr, "|") {
+					t.Errorf("Publish() errors = %q, want %q", got, tt.wantErr)
+				}
+			}
+
+			// a failing sink doesn't keep the message from the following ones
+			for i, stub := range stubs {
+				if len(stub.published) != 1 {
+					t.Errorf("sink %d got %d messages, want 1", i, len(stub.published))
+				}
+			}
+		})
+	}
+}
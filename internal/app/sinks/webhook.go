@@ -0,0 +1,68 @@
+package sinks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const HeaderEventKey = "X-Event-Key"
+
+// WebhookSink posts every message to a URL: the value is the body and the message headers become
+// HTTP headers. Tombstones are skipped. Any status other than 2xx fails the publish.
+type WebhookSink struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+func NewWebhookSink(url string, headers map[string]string, timeout time.Duration) *WebhookSink {
+	return &WebhookSink{
+		url:     url,
+		headers: headers,
+		client:  &http.Client{Timeout: timeout},
+	}
+}
+
+func (s *WebhookSink) Publish(ctx context.Context, key string, value []byte, headers map[string]string) error {
+	if value == nil {
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(value))
+	if err != nil {
+		return err
+	}
+	for name, v := range headers {
+		// underscores are dropped by many proxies, e.g. ce_type is sent as ce-type
+		req.Header.Set(strings.ReplaceAll(name, "_", "-"), v)
+	}
+	for name, v := range s.headers {
+		req.Header.Set(name, v)
+	}
+	req.Header.Set(HeaderEventKey, key)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	// drain the body so the connection is reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with %s", resp.Status)
+	}
+	return nil
+}
+
+func (s *WebhookSink) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
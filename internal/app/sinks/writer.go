@@ -0,0 +1,104 @@
+package sinks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// record is a message written as a line of NDJSON. A value that isn't JSON is kept as a string,
+// a tombstone has a null value.
+type record struct {
+	Key     string            `json:"key"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Value   json.RawMessage   `json:"value"`
+}
+
+func newRecord(key string, value []byte, headers map[string]string) record {
+	rec := record{Key: key, Headers: headers, Value: json.RawMessage("null")}
+	switch {
+	case value == nil:
+	case json.Valid(value):
+		rec.Value = value
+	default:
+		rec.Value, _ = json.Marshal(string(value))
+	}
+	return rec
+}
+
+// WriterSink writes the messages to w as NDJSON.
+type WriterSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+func (s *WriterSink) Publish(_ context.Context, key string, value []byte, headers map[string]string) error {
+	line, err := json.Marshal(newRecord(key, value, headers))
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.w.Write(append(line, '\n'))
+	return err
+}
+
+func (s *WriterSink) Close() error {
+	return nil
+}
+
+// FileSink appends the messages to a file as NDJSON.
+type FileSink struct {
+	WriterSink
+	file  *os.File
+	fsync bool
+}
+
+func NewFileSink(path string, fsync bool) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileSink{WriterSink: WriterSink{w: file}, file: file, fsync: fsync}, nil
+}
+
+func (s *FileSink) Publish(ctx context.Context, key string, value []byte, headers map[string]string) error {
+	if err := s.WriterSink.Publish(ctx, key, value, headers); err != nil {
+		return err
+	}
+	if s.fsync {
+		return s.file.Sync()
+	}
+	return nil
+}
+
+func (s *FileSink) Close() error {
+	return s.file.Close()
+}
+
+// LogSink logs the messages.
+type LogSink struct {
+	logger logrus.FieldLogger
+}
+
+func NewLogSink(logger logrus.FieldLogger) *LogSink {
+	return &LogSink{logger: logger}
+}
+
+func (s *LogSink) Publish(_ context.Context, key string, value []byte, headers map[string]string) error {
+	s.logger.WithField("event", newRecord(key, value, headers)).Info("event published")
+	return nil
+}
+
+func (s *LogSink) Close() error {
+	return nil
+}
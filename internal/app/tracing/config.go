@@ -0,0 +1,64 @@
+package tracing
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/viper"
+)
+
+// Exporters the spans can be sent to.
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+)
+
+type Config struct {
+	// Exporter is where the spans are sent: none, otlp or stdout.
+	Exporter string
+	// Endpoint is the host:port of the OTLP gRPC receiver of the collector.
+	Endpoint string
+	// Insecure turns off TLS for the connection to the collector.
+	Insecure    bool
+	ServiceName string
+	// SampleRatio is the share of the traces started here that are recorded. Traces continued from
+	// an incoming request or message follow the sampling decision of the caller.
+	SampleRatio float64
+}
+
+func LoadTracingConfig() *Config {
+	viper.SetDefault("tracing.exporter", ExporterNone)
+	viper.SetDefault("tracing.endpoint", "localhost:4317")
+	viper.SetDefault("tracing.insecure", true)
+	viper.SetDefault("tracing.service_name", "companies-store")
+	viper.SetDefault("tracing.sample_ratio", 1.0)
+
+	return &Config{
+		Exporter:    viper.GetString("tracing.exporter"),
+		Endpoint:    viper.GetString("tracing.endpoint"),
+		Insecure:    viper.GetBool("tracing.insecure"),
+		ServiceName: viper.GetString("tracing.service_name"),
+		SampleRatio: viper.GetFloat64("tracing.sample_ratio"),
+	}
+}
+
+func (c *Config) Validate() error {
+	var errs []error
+	switch c.Exporter {
+	case ExporterNone, ExporterStdout:
+	case ExporterOTLP:
+		if c.Endpoint == "" {
+			errs = append(errs, errors.New("tracing.endpoint is required by the otlp exporter"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("tracing.exporter must be one of none, otlp or stdout, got %q", c.Exporter))
+	}
+	if c.ServiceName == "" {
+		errs = append(errs, errors.New("tracing.service_name is required"))
+	}
+	if c.SampleRatio < 0 || c.SampleRatio > 1 {
+		errs = append(errs, fmt.Errorf("tracing.sample_ratio must be between 0 and 1, got %v", c.SampleRatio))
+	}
+	return errors.Join(errs...)
+}